@@ -0,0 +1,132 @@
+package dburl
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+)
+
+// Attachment is a database attached to a connection, as specified by the
+// "attach" and "attach_alias" query parameters of a duckdb [URL] (ie,
+// "duckdb:/path/main.db?attach=/path/other.db&attach_alias=other").
+type Attachment struct {
+	// Path is the path of the attached database (ie, "/path/other.db",
+	// "ducklake:metadata.ducklake", "s3://bucket/other.db").
+	Path string
+	// Alias is the alias of the attached database. When empty, the alias is
+	// derived by the database from the path.
+	Alias string
+}
+
+// Statement returns the ATTACH statement for the attachment.
+func (a Attachment) Statement() string {
+	s := "ATTACH '" + strings.ReplaceAll(a.Path, "'", "''") + "'"
+	if a.Alias != "" {
+		s += ` AS "` + strings.ReplaceAll(a.Alias, `"`, `""`) + `"`
+	}
+	return s
+}
+
+// Attachments returns the databases to attach for the URL, as specified by
+// the repeatable "attach" query parameter. Each "attach_alias" query
+// parameter is the alias for the "attach" query parameter in the same
+// position.
+func (u *URL) Attachments() []Attachment {
+	q := u.Query()
+	paths, aliases := q["attach"], q["attach_alias"]
+	var v []Attachment
+	for i, path := range paths {
+		a := Attachment{Path: path}
+		if i < len(aliases) {
+			a.Alias = aliases[i]
+		}
+		v = append(v, a)
+	}
+	return v
+}
+
+// GenDuckDB generates a duckdb DSN from the passed URL. The "attach" and
+// "attach_alias" query parameters are removed from the DSN, and are instead
+// attached when the connection is opened. See [URL.Attachments].
+func GenDuckDB(u *URL) (string, string, error) {
+	if u.Opaque == "" {
+		return "", "", ErrMissingPath
+	}
+	q := u.Query()
+	if len(q["attach_alias"]) > len(q["attach"]) {
+		return "", "", ErrInvalidQuery
+	}
+	return genOpaque(u, stripRawQuery(u.RawQuery, "attach", "attach_alias")), "", nil
+}
+
+// attachConnector is a connector that attaches databases to each new
+// connection.
+type attachConnector struct {
+	drv         driver.Driver
+	dsn         string
+	attachments []Attachment
+}
+
+// openAttach opens a standard [sql.DB] for the URL that attaches the URL's
+// attachments to each new connection.
+func openAttach(name string, u *URL) (*sql.DB, error) {
+	// sql.Open does not connect, and is the only way to retrieve a
+	// registered driver
+	db, err := sql.Open(name, u.DSN)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&attachConnector{drv: drv, dsn: u.DSN, attachments: u.Attachments()}), nil
+}
+
+// Connect satisfies the [driver.Connector] interface.
+func (c *attachConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	var err error
+	if d, ok := c.drv.(driver.DriverContext); ok {
+		var connector driver.Connector
+		if connector, err = d.OpenConnector(c.dsn); err != nil {
+			return nil, err
+		}
+		conn, err = connector.Connect(ctx)
+	} else {
+		conn, err = c.drv.Open(c.dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range c.attachments {
+		if err := execContext(ctx, conn, a.Statement()); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// Driver satisfies the [driver.Connector] interface.
+func (c *attachConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+// execContext executes the query on the driver connection.
+func execContext(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
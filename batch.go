@@ -0,0 +1,45 @@
+package dburl
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+)
+
+// ParseAll parses the URL strings, returning the parsed URLs and the parse
+// errors, both in the same order as the URL strings. For each URL string,
+// either its URL or its error is nil. Useful for ingesting inventories of
+// many connection strings, where a single invalid URL string should not
+// prevent parsing the remaining.
+func ParseAll(urls []string) ([]*URL, []error) {
+	o := newOptions(context.Background())
+	v, errs := make([]*URL, len(urls)), make([]error, len(urls))
+	for i, s := range urls {
+		v[i], errs[i] = parse(s, o)
+	}
+	return v, errs
+}
+
+// ParseReader parses the URL strings read from the reader, one per line,
+// calling f with the line number, and the parsed URL or parse error, for
+// each URL string. Leading and trailing whitespace is trimmed, and blank
+// lines and lines starting with '#' are skipped.
+//
+// Parsing stops when f returns a non-nil error, which is returned. Otherwise,
+// the first error reading from the reader, if any, is returned.
+func ParseReader(r io.Reader, f func(line int, u *URL, err error) error) error {
+	o := newOptions(context.Background())
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		urlstr := strings.TrimSpace(s.Text())
+		if urlstr == "" || strings.HasPrefix(urlstr, "#") {
+			continue
+		}
+		u, err := parse(urlstr, o)
+		if err := f(line, u, err); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
@@ -0,0 +1,184 @@
+package dburl
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// CheckStage is a health check stage.
+type CheckStage string
+
+// Health check stages.
+const (
+	// CheckStageDial is the network dial stage.
+	CheckStageDial CheckStage = "dial"
+	// CheckStageTLS is the TLS handshake and verification stage.
+	CheckStageTLS CheckStage = "tls"
+	// CheckStageConnect is the driver connect and authentication stage.
+	CheckStageConnect CheckStage = "connect"
+	// CheckStagePing is the ping query stage.
+	CheckStagePing CheckStage = "ping"
+)
+
+// StageTiming is the timing of a completed health check stage.
+type StageTiming struct {
+	// Stage is the stage.
+	Stage CheckStage
+	// Duration is the duration of the stage.
+	Duration time.Duration
+}
+
+// CheckResult is the result of a health check. See [Check].
+type CheckResult struct {
+	// URL is the redacted URL.
+	URL string
+	// Network is the dialed network, if any.
+	Network string
+	// Addr is the dialed address, if any.
+	Addr string
+	// Stages are the completed stages, in order.
+	Stages []StageTiming
+	// Total is the total duration of the check.
+	Total time.Duration
+}
+
+// Duration returns the duration of the stage, and whether the stage was
+// completed.
+func (res *CheckResult) Duration(stage CheckStage) (time.Duration, bool) {
+	for _, st := range res.Stages {
+		if st.Stage == stage {
+			return st.Duration, true
+		}
+	}
+	return 0, false
+}
+
+// Check performs a health check of the database for a URL string, returning
+// the timing of each stage:
+//
+//   - dial: dials the URL's network address (see [DialAddr]), skipped for
+//     URLs without a network address (ie, file based databases)
+//   - tls: performs the TLS handshake and verification, when the URL requests
+//     TLS and the handshake can be performed without the driver's protocol
+//     (ie, "https" transports, and postgres' SSLRequest)
+//   - connect: opens and authenticates a connection using the driver
+//   - ping: runs the driver's ping query (see [Ping])
+//
+// When a stage fails, the result containing the completed stages is returned
+// along with a [CheckError].
+func Check(ctx context.Context, urlstr string) (*CheckResult, error) {
+	u, err := ParseContext(ctx, urlstr)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	res := &CheckResult{
+		URL: u.Redacted(),
+	}
+	err = res.check(ctx, u)
+	res.Total = time.Since(start)
+	return res, err
+}
+
+// check performs the health check stages.
+func (res *CheckResult) check(ctx context.Context, u *URL) error {
+	// dial
+	network, addr, tlsConfig, err := DialAddr(u)
+	switch {
+	case errors.Is(err, ErrNoNetworkAddress):
+	case err != nil:
+		return &CheckError{Stage: CheckStageDial, Err: err}
+	default:
+		res.Network, res.Addr = network, addr
+		var conn net.Conn
+		if err := res.stage(CheckStageDial, func() error {
+			var d net.Dialer
+			conn, err = d.DialContext(ctx, network, addr)
+			return err
+		}); err != nil {
+			return err
+		}
+		defer conn.Close()
+		// tls
+		if pre, ok := tlsPreamble(u); ok && tlsConfig != nil {
+			if err := res.stage(CheckStageTLS, func() error {
+				return handshake(ctx, conn, tlsConfig, pre)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	// connect
+	db, err := OpenURL(u)
+	if err != nil {
+		return &CheckError{Stage: CheckStageConnect, Err: err}
+	}
+	defer db.Close()
+	if err := res.stage(CheckStageConnect, func() error {
+		return db.PingContext(ctx)
+	}); err != nil {
+		return err
+	}
+	// ping
+	return res.stage(CheckStagePing, func() error {
+		return Ping(ctx, db, u)
+	})
+}
+
+// stage runs and times the stage, adding it to the completed stages on
+// success.
+func (res *CheckResult) stage(stage CheckStage, f func() error) error {
+	start := time.Now()
+	if err := f(); err != nil {
+		return &CheckError{Stage: stage, Err: err}
+	}
+	res.Stages = append(res.Stages, StageTiming{
+		Stage:    stage,
+		Duration: time.Since(start),
+	})
+	return nil
+}
+
+// pgSSLRequest is the postgres SSLRequest message.
+var pgSSLRequest = []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}
+
+// tlsPreamble returns the preamble required before the TLS handshake for the
+// URL, and whether the TLS handshake can be performed without the driver.
+func tlsPreamble(u *URL) ([]byte, bool) {
+	switch {
+	case u.Transport == "https":
+		return nil, true
+	case u.envFamily() == "postgres":
+		return pgSSLRequest, true
+	}
+	return nil, false
+}
+
+// handshake performs the TLS handshake on the connection, after writing the
+// preamble, if any.
+func handshake(ctx context.Context, conn net.Conn, tlsConfig *tls.Config, pre []byte) error {
+	if pre != nil {
+		if deadline, ok := ctx.Deadline(); ok {
+			if err := conn.SetDeadline(deadline); err != nil {
+				return err
+			}
+			defer conn.SetDeadline(time.Time{})
+		}
+		if _, err := conn.Write(pre); err != nil {
+			return err
+		}
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return err
+		}
+		if buf[0] != 'S' {
+			return fmt.Errorf("server does not support tls (%q)", buf[0])
+		}
+	}
+	return tls.Client(conn, tlsConfig).HandshakeContext(ctx)
+}
@@ -0,0 +1,130 @@
+package dburl
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// Info is the classification of a URL, as returned by [Classify].
+type Info struct {
+	// Family is the database family (ie, "postgres" for cockroachdb, pgx, and
+	// redshift URLs, "mysql" for memsql, mymysql, tidb, and vitess URLs).
+	Family string
+	// Driver is the unaliased driver name (ie, "cockroachdb", "pgx").
+	Driver string
+	// Transport is the transport protocol (ie, "tcp", "unix").
+	Transport string
+	// Host is the host name, or the socket path or directory for unix
+	// transports.
+	Host string
+	// Port is the port, or the scheme's default port when not specified.
+	Port string
+	// Database is the database name.
+	Database string
+	// TLS is true when the URL requests TLS (ie, "sslmode=require",
+	// "tls=true", "encrypt=true", or a "+https" transport).
+	TLS bool
+}
+
+// Classify parses the URL string and classifies it, returning the database
+// family, driver, host, port, database, and whether TLS is expected.
+//
+// Classify does not require any Go SQL driver to be imported, and never
+// generates a DSN, making it suitable for monitoring and inventory tools that
+// only need to identify a database.
+func Classify(urlstr string) (Info, error) {
+	o := newOptions(context.Background())
+	o.classify = true
+	u, err := parse(urlstr, o)
+	if err != nil {
+		return Info{}, err
+	}
+	scheme, _ := lookupScheme(u.Scheme)
+	info := Info{
+		Family:    family(scheme),
+		Driver:    u.UnaliasedDriver,
+		Transport: u.Transport,
+		Host:      u.Hostname(),
+		Port:      u.Port(),
+		Database:  strings.TrimPrefix(u.Path, "/"),
+		TLS:       expectsTLS(u),
+	}
+	// socket specified via query
+	q := u.Query()
+	switch {
+	case info.Host == "" && info.Family == "postgres" && (strings.HasPrefix(q.Get("host"), "/") || strings.HasPrefix(q.Get("host"), "@")):
+		info.Transport, info.Host, info.Port = "unix", q.Get("host"), q.Get("port")
+		return info, nil
+	case info.Host == "" && info.Family == "mysql" && q.Get("socket") != "":
+		info.Transport, info.Host = "unix", q.Get("socket")
+		return info, nil
+	}
+	switch {
+	case u.Transport == "unix":
+		s := u.opaqueOrPath()
+		if info.Host != "" {
+			s = path.Join(info.Host, s)
+		}
+		switch info.Family {
+		case "postgres":
+			info.Host, info.Port, info.Database = resolveDir(s, u.opts)
+		case "mysql":
+			info.Host, info.Database = resolveSocket(s, u.opts)
+		default:
+			info.Host, info.Database = s, ""
+		}
+	case scheme.Opaque:
+		info.Host, info.Database = "", u.Opaque
+	case info.Port == "" && u.Transport == "tcp":
+		info.Port = scheme.DefaultPort
+	}
+	// sqlserver urls are "host/instance/dbname"
+	if info.Family == "sqlserver" && u.Transport == "tcp" {
+		if db := q.Get("database"); db != "" {
+			info.Database = db
+		} else if i := strings.LastIndex(info.Database, "/"); i != -1 {
+			info.Database = info.Database[i+1:]
+		}
+	}
+	return info, nil
+}
+
+// families are the database families of drivers that are not apparent from
+// the scheme's Override.
+var families = map[string]string{
+	"dolt":          "mysql",
+	"godror":        "oracle",
+	"moderncsqlite": "sqlite3",
+	"mymysql":       "mysql",
+	"oleodbc":       "odbc",
+	"pgx":           "postgres",
+	"tds":           "sqlserver",
+}
+
+// family returns the database family for the scheme.
+func family(scheme *Scheme) string {
+	switch f, ok := families[scheme.Driver]; {
+	case ok:
+		return f
+	case scheme.Override != "":
+		return scheme.Override
+	}
+	return scheme.Driver
+}
+
+// expectsTLS returns true when the URL requests TLS.
+func expectsTLS(u *URL) bool {
+	if strings.HasSuffix(u.OriginalScheme, "+https") {
+		return true
+	}
+	q := u.Query()
+	for _, k := range tlsParams {
+		switch v := strings.ToLower(q.Get(k)); v {
+		case "", "0", "allow", "disable", "false", "no", "off", "optional", "prefer", "preferred":
+		default:
+			return true
+		}
+	}
+	return false
+}
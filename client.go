@@ -0,0 +1,125 @@
+package dburl
+
+import (
+	"sort"
+)
+
+// ClientCommand returns the native command-line client (ie, psql, mysql,
+// sqlcmd, sqlite3, duckdb) invocation equivalent to the URL, for tools that
+// shell out to a database's client for features the Go SQL drivers lack.
+//
+// The returned environment variables ("KEY=value") should be appended to
+// the process' environment (see [os.Environ]) when executing the command.
+// Passwords are only passed through the environment, and never as
+// arguments. Returns [ErrUnsupportedClient] when the URL's database does
+// not have a known client.
+func ClientCommand(u *URL) (string, []string, []string, error) {
+	return command(u, "client")
+}
+
+// DumpCommand returns the native dump command (ie, pg_dump, mysqldump,
+// sqlite3 .dump) invocation for the URL's database, writing a plain SQL dump
+// to standard output that can be restored using the command returned by
+// [RestoreCommand].
+//
+// As with [ClientCommand], passwords are only passed through the returned
+// environment variables. Returns [ErrUnsupportedClient] when the URL's
+// database does not have a known dump command.
+func DumpCommand(u *URL) (string, []string, []string, error) {
+	return command(u, "dump")
+}
+
+// RestoreCommand returns the native client invocation for the URL's
+// database that restores a plain SQL dump (as written by the command returned
+// by [DumpCommand]) read from standard input.
+//
+// As with [ClientCommand], passwords are only passed through the returned
+// environment variables. Returns [ErrUnsupportedClient] when the URL's
+// database does not have a known restore command.
+func RestoreCommand(u *URL) (string, []string, []string, error) {
+	return command(u, "restore")
+}
+
+// command returns the native command, arguments, and environment for the
+// operation ("client", "dump", or "restore") on the URL's database.
+func command(u *URL, op string) (string, []string, []string, error) {
+	switch f := u.envFamily(); {
+	case f == "postgres":
+		switch op {
+		case "client":
+			return "psql", nil, envList(u.Env("")), nil
+		case "dump":
+			return "pg_dump", nil, envList(u.Env("")), nil
+		case "restore":
+			return "psql", []string{"--single-transaction", "--set=ON_ERROR_STOP=1"}, envList(u.Env("")), nil
+		}
+	case f == "mysql":
+		args, env := mysqlArgs(u)
+		dbname := u.Env("")["MYSQL_DATABASE"]
+		switch {
+		case dbname != "":
+			args = append(args, dbname)
+		case op == "dump":
+			args = append(args, "--all-databases")
+		}
+		if op == "dump" {
+			return "mysqldump", args, env, nil
+		}
+		return "mysql", args, env, nil
+	case f == "sqlserver" && op == "client":
+		return "sqlcmd", nil, envList(u.Env("")), nil
+	case f == "sqlite3", f == "duckdb" && op == "client":
+		name, err := filePath(u)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		args := []string{name}
+		if op == "dump" {
+			args = append(args, ".dump")
+		}
+		return f, args, nil, nil
+	}
+	return "", nil, nil, ErrUnsupportedClient
+}
+
+// mysqlArgs returns the mysql client connection arguments and environment
+// for the URL. The password is passed via the environment.
+func mysqlArgs(u *URL) ([]string, []string) {
+	m := u.Env("")
+	var args, env []string
+	for _, v := range []struct {
+		name, arg string
+	}{
+		{"MYSQL_HOST", "--host="},
+		{"MYSQL_UNIX_PORT", "--socket="},
+		{"MYSQL_TCP_PORT", "--port="},
+		{"MYSQL_USER", "--user="},
+	} {
+		if s, ok := m[v.name]; ok {
+			args = append(args, v.arg+s)
+		}
+	}
+	if s, ok := m["MYSQL_PWD"]; ok {
+		env = append(env, "MYSQL_PWD="+s)
+	}
+	return args, env
+}
+
+// filePath returns the database file path of a file based URL.
+func filePath(u *URL) (string, error) {
+	if u.Opaque == "" {
+		return "", ErrMissingPath
+	}
+	return opaquePath(u.Opaque), nil
+}
+
+// envList returns the environment variables as a sorted list of "KEY=value"
+// strings.
+func envList(m map[string]string) []string {
+	var v []string
+	for k, s := range m {
+		v = append(v, k+"="+s)
+	}
+	sort.Strings(v)
+	return v
+}
@@ -0,0 +1,107 @@
+// Package cloud provides an opt-in open hook for connecting to managed cloud
+// databases (ie, Cloud SQL, RDS with IAM authentication), specified with the
+// "cloud" query parameter of a database URL:
+//
+//	pg://user@mydb.example.com/mydb?cloud=aws
+//
+// As cloud connectors require packages outside of Go's standard library (ie,
+// a cloud provider's SDK), connections are prepared by a [Provider]
+// registered for the query parameter's value:
+//
+//	import _ "github.com/xo/dburl/cloud"
+//
+//	cloud.Register("aws", func(ctx context.Context, u *dburl.URL) (*dburl.URL, error) {
+//		// return the URL with an IAM authentication token as its password
+//	})
+//
+// When opened with [dburl.Open], the URL returned by the provider is opened.
+package cloud
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xo/dburl"
+)
+
+func init() {
+	dburl.RegisterOpenHook(Hook)
+}
+
+// Provider is a func that prepares a URL for connecting to a cloud provider's
+// managed database, returning the URL to open (ie, with the host of a local
+// connector proxy, or with a short-lived authentication token as the
+// password).
+type Provider func(ctx context.Context, u *dburl.URL) (*dburl.URL, error)
+
+// providers are the registered providers.
+var providers struct {
+	sync.RWMutex
+	m map[string]Provider
+}
+
+// Register registers the provider for the "cloud" query parameter value (ie,
+// "aws", "gcp", "azure").
+func Register(name string, f Provider) {
+	if f == nil {
+		panic("must specify a non-nil Provider")
+	}
+	providers.Lock()
+	defer providers.Unlock()
+	if providers.m == nil {
+		providers.m = make(map[string]Provider)
+	}
+	providers.m[strings.ToLower(name)] = f
+}
+
+// Hook is the [dburl.OpenHook] registered by the package, preparing URLs
+// having a "cloud" query parameter with the registered provider prior to
+// opening.
+func Hook(next dburl.OpenFunc) dburl.OpenFunc {
+	return func(u *dburl.URL) (*sql.DB, error) {
+		z, err := Prepare(context.Background(), u)
+		if err != nil {
+			return nil, err
+		}
+		return next(z)
+	}
+}
+
+// Prepare prepares the URL with the provider of its "cloud" query parameter,
+// passing the provider the URL with the "cloud" query parameter removed.
+// Returns the URL unchanged when it has no "cloud" query parameter.
+func Prepare(ctx context.Context, u *dburl.URL) (*dburl.URL, error) {
+	q := u.Query()
+	if !q.Has("cloud") {
+		return u, nil
+	}
+	name := q.Get("cloud")
+	providers.RLock()
+	f, ok := providers.m[strings.ToLower(name)]
+	providers.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("cloud %q: %w", name, ErrUnknownProvider)
+	}
+	q.Del("cloud")
+	v := u.URL
+	v.RawQuery = q.Encode()
+	z, err := dburl.Parse((&dburl.URL{URL: v, OriginalScheme: u.OriginalScheme}).String())
+	if err != nil {
+		return nil, err
+	}
+	return f(ctx, z)
+}
+
+// Error is a error.
+type Error string
+
+// Error satisfies the error interface.
+func (err Error) Error() string {
+	return string(err)
+}
+
+// ErrUnknownProvider is the unknown provider error.
+const ErrUnknownProvider Error = "unknown provider"
@@ -0,0 +1,70 @@
+package cloud
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/xo/dburl"
+)
+
+func TestPrepare(t *testing.T) {
+	Register("test", func(_ context.Context, u *dburl.URL) (*dburl.URL, error) {
+		v := u.URL
+		v.User = url.UserPassword(u.User.Username(), "token")
+		return dburl.Parse((&dburl.URL{URL: v, OriginalScheme: u.OriginalScheme}).String())
+	})
+	tests := []struct {
+		s   string
+		exp string
+		err error
+	}{
+		{"pg://user@mydb.example.com/mydb?cloud=test&sslmode=require", "dbname=mydb host=mydb.example.com password=token sslmode=require user=user", nil},
+		{"my://user@mydb.example.com/mydb?cloud=TEST", "user:token@tcp(mydb.example.com:3306)/mydb", nil},
+		{"pg://user:pass@mydb.example.com/mydb", "dbname=mydb host=mydb.example.com password=pass user=user", nil},
+		{"pg://user@mydb.example.com/mydb?cloud=gcp", "", ErrUnknownProvider},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			u, err := dburl.Parse(test.s)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			z, err := Prepare(context.Background(), u)
+			switch {
+			case test.err != nil && !errors.Is(err, test.err):
+				t.Fatalf("expected error %v, got: %v", test.err, err)
+			case test.err != nil:
+			case err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			case z.DSN != test.exp:
+				t.Errorf("expected %q, got: %q", test.exp, z.DSN)
+			}
+		})
+	}
+}
+
+func TestHook(t *testing.T) {
+	errProvider := errors.New("provider error")
+	Register("fail", func(context.Context, *dburl.URL) (*dburl.URL, error) {
+		return nil, errProvider
+	})
+	var called bool
+	f := Hook(func(*dburl.URL) (*sql.DB, error) {
+		called = true
+		return nil, nil
+	})
+	u, err := dburl.Parse("pg://user@mydb.example.com/mydb?cloud=fail")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := f(u); !errors.Is(err, errProvider) {
+		t.Errorf("expected error %v, got: %v", errProvider, err)
+	}
+	if called {
+		t.Errorf("expected next to not be called")
+	}
+}
@@ -0,0 +1,222 @@
+// Package conformance provides a conformance suite for dburl schemes,
+// checking that a registered scheme's generator consistently handles bare
+// URLs, user info, ports, query parameters, and round-trips via
+// [dburl.URL.String].
+//
+// Authors of downstream schemes can run the suite in their own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		dburl.Register(dburl.Scheme{Driver: "mydb", ...})
+//		conformance.Run(t, "mydb")
+//	}
+package conformance
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/xo/dburl"
+)
+
+// Check is a conformance check name.
+type Check string
+
+// Conformance checks.
+const (
+	// CheckBare checks that a bare URL (ie, "mydb://") either fails to parse,
+	// or parses to the scheme's driver and generates the same DSN when parsed
+	// again.
+	CheckBare Check = "bare"
+	// CheckUserInfo checks that the URL's user name and password are
+	// propagated to the DSN.
+	CheckUserInfo Check = "userinfo"
+	// CheckPort checks that the URL's port is propagated to the DSN, and that
+	// when the URL does not specify a port, the DSN does not contain a port
+	// other than the scheme's default port, if any.
+	CheckPort Check = "port"
+	// CheckQuery checks that the URL's query parameters are propagated to the
+	// DSN.
+	CheckQuery Check = "query"
+	// CheckRoundTrip checks that the URL's string form parses to the same
+	// driver and DSN.
+	CheckRoundTrip Check = "roundtrip"
+)
+
+// Checks returns all conformance checks.
+func Checks() []Check {
+	return []Check{
+		CheckBare,
+		CheckUserInfo,
+		CheckPort,
+		CheckQuery,
+		CheckRoundTrip,
+	}
+}
+
+// Exceptions are the checks skipped for the built-in schemes, keyed by
+// driver, where the driver does not accept the corresponding URL component.
+var Exceptions = map[string][]Check{
+	"cosmos":   {CheckUserInfo},
+	"d1":       {CheckUserInfo, CheckPort},
+	"godror":   {CheckQuery},
+	"godynamo": {CheckPort},
+	"sqld":     {CheckUserInfo},
+	"voltdb":   {CheckUserInfo, CheckQuery},
+}
+
+// Run runs the conformance checks for the named registered scheme as
+// subtests, skipping the passed checks.
+func Run(t *testing.T, name string, skip ...Check) {
+	t.Helper()
+	var scheme *dburl.Scheme
+	for _, z := range dburl.Schemes() {
+		if z.Driver == name || contains(z.Aliases, name) {
+			scheme = &z
+			break
+		}
+	}
+	if scheme == nil {
+		t.Fatalf("scheme %q is not registered", name)
+	}
+	for _, check := range Checks() {
+		if contains(skip, check) {
+			continue
+		}
+		t.Run(string(check), func(t *testing.T) {
+			funcs[check](t, *scheme)
+		})
+	}
+}
+
+// RunAll runs the conformance checks for all registered schemes as subtests,
+// named by driver, skipping the checks listed in [Exceptions].
+func RunAll(t *testing.T) {
+	t.Helper()
+	for _, scheme := range dburl.Schemes() {
+		name := scheme.Driver
+		t.Run(name, func(t *testing.T) {
+			Run(t, name, Exceptions[name]...)
+		})
+	}
+}
+
+// funcs are the check funcs.
+var funcs = map[Check]func(*testing.T, dburl.Scheme){
+	CheckBare:      checkBare,
+	CheckUserInfo:  checkUserInfo,
+	CheckPort:      checkPort,
+	CheckQuery:     checkQuery,
+	CheckRoundTrip: checkRoundTrip,
+}
+
+// checkBare checks a bare URL.
+func checkBare(t *testing.T, scheme dburl.Scheme) {
+	urlstr := scheme.Driver + "://"
+	if scheme.Opaque {
+		urlstr = scheme.Driver + ":"
+	}
+	u := parse(t, urlstr)
+	if u.UnaliasedDriver != scheme.Driver {
+		t.Errorf("%q expected driver %q, got: %q", urlstr, scheme.Driver, u.UnaliasedDriver)
+	}
+	v := parse(t, urlstr)
+	if v.DSN != u.DSN {
+		t.Errorf("%q expected dsn %q, got: %q", urlstr, u.DSN, v.DSN)
+	}
+}
+
+// checkUserInfo checks user name and password propagation.
+func checkUserInfo(t *testing.T, scheme dburl.Scheme) {
+	if scheme.Opaque {
+		t.Skip("opaque scheme")
+	}
+	urlstr := scheme.Driver + "://conformanceuser:conformancepass@localhost/conformancedb"
+	u := parse(t, urlstr)
+	for _, s := range []string{"conformanceuser", "conformancepass"} {
+		if !strings.Contains(u.DSN, s) {
+			t.Errorf("%q expected dsn to contain %q, got: %q", urlstr, s, u.DSN)
+		}
+	}
+}
+
+// checkPort checks port propagation and defaulting.
+func checkPort(t *testing.T, scheme dburl.Scheme) {
+	if scheme.Opaque {
+		t.Skip("opaque scheme")
+	}
+	urlstr := scheme.Driver + "://localhost:1234/conformancedb"
+	if u := parse(t, urlstr); !strings.Contains(u.DSN, "1234") {
+		t.Errorf("%q expected dsn to contain port 1234, got: %q", urlstr, u.DSN)
+	}
+	if scheme.DefaultPort == "" {
+		return
+	}
+	urlstr = scheme.Driver + "://localhost/conformancedb"
+	u := parse(t, urlstr)
+	for _, m := range portRE.FindAllStringSubmatch(u.DSN, -1) {
+		if m[1] != scheme.DefaultPort {
+			t.Errorf("%q expected dsn to contain no port or default port %q, got: %q", urlstr, scheme.DefaultPort, u.DSN)
+		}
+	}
+}
+
+// portRE matches a localhost port.
+var portRE = regexp.MustCompile(`localhost:(\d+)`)
+
+// checkQuery checks query parameter propagation.
+func checkQuery(t *testing.T, scheme dburl.Scheme) {
+	urlstr := scheme.Driver + "://localhost/conformancedb?conformancekey=conformancevalue"
+	if scheme.Opaque {
+		urlstr = scheme.Driver + ":/conformance/conformance.db?conformancekey=conformancevalue"
+	}
+	u := parse(t, urlstr)
+	for _, s := range []string{"conformancekey", "conformancevalue"} {
+		if !strings.Contains(u.DSN, s) {
+			t.Errorf("%q expected dsn to contain %q, got: %q", urlstr, s, u.DSN)
+		}
+	}
+}
+
+// checkRoundTrip checks that the URL's string form parses to the same driver
+// and DSN.
+func checkRoundTrip(t *testing.T, scheme dburl.Scheme) {
+	urlstr := scheme.Driver + "://conformanceuser:conformancepass@localhost:1234/conformancedb?conformancekey=conformancevalue"
+	if scheme.Opaque {
+		urlstr = scheme.Driver + ":/conformance/conformance.db?conformancekey=conformancevalue"
+	}
+	u := parse(t, urlstr)
+	s := u.String()
+	v, err := dburl.Parse(s)
+	switch {
+	case err != nil:
+		t.Fatalf("%q expected no error, got: %v", s, err)
+	case v.Driver != u.Driver:
+		t.Errorf("%q expected driver %q, got: %q", s, u.Driver, v.Driver)
+	case v.GoDriver != u.GoDriver:
+		t.Errorf("%q expected go driver %q, got: %q", s, u.GoDriver, v.GoDriver)
+	case v.DSN != u.DSN:
+		t.Errorf("%q expected dsn %q, got: %q", s, u.DSN, v.DSN)
+	}
+}
+
+// parse parses the URL, skipping the test when the scheme does not accept
+// the URL.
+func parse(t *testing.T, urlstr string) *dburl.URL {
+	t.Helper()
+	u, err := dburl.Parse(urlstr)
+	if err != nil {
+		t.Skipf("%q does not parse: %v", urlstr, err)
+	}
+	return u
+}
+
+// contains returns true when v contains s.
+func contains[T comparable](v []T, s T) bool {
+	for _, z := range v {
+		if z == s {
+			return true
+		}
+	}
+	return false
+}
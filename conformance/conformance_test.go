@@ -0,0 +1,22 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/xo/dburl"
+)
+
+func TestBuiltin(t *testing.T) {
+	RunAll(t)
+}
+
+func TestRun(t *testing.T) {
+	dburl.Register(dburl.Scheme{
+		Driver:      "xconformance",
+		Generator:   dburl.GenFromURL("xconformance://localhost:4321/"),
+		Aliases:     []string{"xc"},
+		DefaultPort: "4321",
+	})
+	defer dburl.Unregister("xconformance")
+	Run(t, "xc")
+}
@@ -0,0 +1,17 @@
+package dburl_test
+
+import (
+	"testing"
+
+	"github.com/xo/dburl"
+	"github.com/xo/dburl/dburltest"
+)
+
+func TestParse(t *testing.T) {
+	prev := dburl.OdbcIgnoreQueryPrefixes
+	t.Cleanup(func() {
+		dburl.OdbcIgnoreQueryPrefixes = prev
+	})
+	dburl.OdbcIgnoreQueryPrefixes = []string{"usql_"}
+	dburltest.RunCorpusFile(t, "testdata/parse.txt")
+}
@@ -0,0 +1,95 @@
+package dburl
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// credentialsDirParam is the query parameter specifying a directory of
+// file-mounted credentials (ie, "credentials_dir=/var/run/secrets/db").
+const credentialsDirParam = "credentials_dir"
+
+// ResolveCredentials returns a new URL with the user name, password, and TLS
+// certificates read from the files in the directory specified by the URL's
+// "credentials_dir" query parameter, matching the convention used by
+// Kubernetes secret mounts and Vault agent. When the URL does not have a
+// "credentials_dir", the URL is returned unchanged.
+//
+// The "username" (or "user") and "password" files are read, with surrounding
+// whitespace trimmed. For postgres URLs, the "ca.crt", "tls.crt", and
+// "tls.key" files are used as the "sslrootcert", "sslcert", and "sslkey"
+// parameters when present. Files are read using [OpenFile].
+//
+// Called by [OpenURL], so that rotated credentials are used for each Open.
+func ResolveCredentials(u *URL) (*URL, error) {
+	q := u.Query()
+	dir := q.Get(credentialsDirParam)
+	if dir == "" {
+		return u, nil
+	}
+	if _, err := Stat(dir); err != nil {
+		return nil, err
+	}
+	z := u.URL
+	// user and password
+	user, err := readCredential(dir, "username", "user")
+	if err != nil {
+		return nil, err
+	}
+	pass, err := readCredential(dir, "password")
+	if err != nil {
+		return nil, err
+	}
+	if user == "" && z.User != nil {
+		user = z.User.Username()
+	}
+	switch {
+	case pass != "":
+		z.User = url.UserPassword(user, pass)
+	case user != "":
+		if p, ok := z.User.Password(); ok {
+			z.User = url.UserPassword(user, p)
+		} else {
+			z.User = url.User(user)
+		}
+	}
+	// tls certificates
+	if scheme, ok := lookupScheme(u.Scheme); ok && family(scheme) == "postgres" {
+		for _, f := range [][2]string{{"ca.crt", "sslrootcert"}, {"tls.crt", "sslcert"}, {"tls.key", "sslkey"}} {
+			name := filepath.Join(dir, f[0])
+			if _, err := Stat(name); err == nil && !q.Has(f[1]) {
+				q.Set(f[1], name)
+			}
+		}
+		z.RawQuery = q.Encode()
+	}
+	o := u.opts
+	if o == nil {
+		o = newOptions(context.Background())
+	}
+	return parse((&URL{URL: z, OriginalScheme: u.OriginalScheme}).String(), o)
+}
+
+// readCredential reads the first existing file in dir.
+func readCredential(dir string, names ...string) (string, error) {
+	for _, name := range names {
+		name = filepath.Join(dir, name)
+		if _, err := Stat(name); err != nil {
+			continue
+		}
+		f, err := OpenFile(name)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		buf, err := io.ReadAll(f)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(buf)), nil
+	}
+	return "", nil
+}
@@ -10,15 +10,21 @@
 package dburl
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
 // ResolveSchemeType is a configuration setting to open paths on disk using
@@ -36,22 +42,84 @@ func Open(urlstr string) (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	driver := u.Driver
-	if u.GoDriver != "" {
-		driver = u.GoDriver
-	}
-	return sql.Open(driver, u.DSN)
+	return OpenURL(u)
 }
 
 // OpenMap takes a map of URL components and opens a standard [sql.DB] connection.
+// Errors building, parsing, or opening the URL are wrapped in an [OpenError].
 //
 // See [BuildURL] for information on the recognized map components.
 func OpenMap(components map[string]interface{}) (*sql.DB, error) {
 	urlstr, err := BuildURL(components)
 	if err != nil {
-		return nil, err
+		return nil, &OpenError{Op: "build", Err: err}
+	}
+	u, err := Parse(urlstr)
+	if err != nil {
+		return nil, &OpenError{Op: "parse", Err: err}
 	}
-	return Open(urlstr)
+	db, err := OpenURL(u)
+	if err != nil {
+		return nil, &OpenError{Op: "open", URL: u.Redacted(), Err: err}
+	}
+	return db, nil
+}
+
+// Components are typed URL components. See [BuildURL].
+type Components struct {
+	// Protocol is the scheme name or alias (ie, "pg", "mysql").
+	Protocol string
+	// Transport is the transport protocol (ie, "tcp", "unix"), if any.
+	Transport string
+	// User is the user name, if any.
+	User string
+	// Password is the password, if any.
+	Password string
+	// Host is the host name, if any.
+	Host string
+	// Port is the port, if any.
+	Port string
+	// Path is the path, file, or opaque component, if any. When set, Instance
+	// and Database are ignored.
+	Path string
+	// Instance is the instance name, if any.
+	Instance string
+	// Database is the database name, if any.
+	Database string
+	// Query are the query parameters, if any.
+	Query url.Values
+}
+
+// Map returns the components as a map, as used by [BuildURL].
+func (c Components) Map() map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, kv := range []struct {
+		k, v string
+	}{
+		{"protocol", c.Protocol},
+		{"transport", c.Transport},
+		{"user", c.User},
+		{"password", c.Password},
+		{"host", c.Host},
+		{"port", c.Port},
+		{"path", c.Path},
+		{"instance", c.Instance},
+		{"database", c.Database},
+		{"query", c.Query.Encode()},
+	} {
+		if kv.v != "" {
+			m[kv.k] = kv.v
+		}
+	}
+	return m
+}
+
+// OpenComponents builds, parses, and opens a standard [sql.DB] connection
+// for the components. Errors are wrapped in an [OpenError].
+//
+// See [OpenMap].
+func OpenComponents(c Components) (*sql.DB, error) {
+	return OpenMap(c.Map())
 }
 
 // URL wraps the standard [net/url.URL] type, adding OriginalScheme, Transport,
@@ -76,12 +144,23 @@ type URL struct {
 	// DSN is the built connection "data source name" that can be used in a
 	// call to [sql.Open].
 	DSN string
+	// Diagnostics are the non-fatal problems found when parsing (ie, a
+	// password sent over a plaintext connection). See [URL.Lint].
+	Diagnostics []Problem
 	// hostPortDB will be set by Gen*() funcs after determining the host, port,
 	// database.
 	//
 	// When empty, indicates that these values are not special, and can be
 	// retrieved as the host, port, and path[1:] as usual.
 	hostPortDB []string
+	// opts are the parse options.
+	opts *options
+}
+
+// KV is a query parameter key/value pair.
+type KV struct {
+	Key   string
+	Value string
 }
 
 // Parse parses a URL string, similar to the standard [net/url.Parse].
@@ -93,24 +172,55 @@ type URL struct {
 // "scheme://" but "scheme:"), and the database scheme does not support opaque
 // components, Parse will attempt to re-process the URL as "scheme://<opaque>".
 func Parse(urlstr string) (*URL, error) {
-	// parse url
-	v, err := url.Parse(urlstr)
+	return ParseWithOptions(urlstr)
+}
+
+// ParseWithOptions parses a URL string using the specified options.
+//
+// See [Parse] for more information.
+func ParseWithOptions(urlstr string, opts ...Option) (*URL, error) {
+	return ParseContext(context.Background(), urlstr, opts...)
+}
+
+// ParseContext parses a URL string using the context and the specified
+// options. The context is passed to the scheme's generator, allowing slow DSN
+// generation (ie, file resolution or token acquisition) to be cancelled.
+//
+// See [Parse] for more information.
+func ParseContext(ctx context.Context, urlstr string, opts ...Option) (*URL, error) {
+	return parse(urlstr, newOptions(ctx, opts...))
+}
+
+// parse parses a URL string using the options.
+func parse(urlstr string, o *options) (*URL, error) {
+	if err := o.ctx.Err(); err != nil {
+		return nil, err
+	}
+	// expand shorthand
+	urlstr, err := o.shorthandString(escapeUserinfo(urlstr))
+	if err != nil {
+		return nil, err
+	}
+	// parse url, with multiple hosts parsed separately
+	s, hosts, err := cutHosts(urlstr)
+	if err != nil {
+		return nil, err
+	}
+	v, err := url.Parse(s)
 	switch {
 	case err != nil:
 		return nil, err
 	case v.Scheme == "":
-		if ResolveSchemeType {
-			if typ, err := SchemeType(urlstr); err == nil {
-				return Parse(typ + ":" + urlstr)
-			}
-		}
 		return nil, ErrInvalidDatabaseScheme
+	case hosts != "":
+		v.Host = hosts
 	}
 	// create url
 	u := &URL{
 		URL:            *v,
 		OriginalScheme: urlstr[:len(v.Scheme)],
 		Transport:      "tcp",
+		opts:           o,
 	}
 	// check for +transport in scheme
 	var checkTransport bool
@@ -118,12 +228,29 @@ func Parse(urlstr string) (*URL, error) {
 		u.Transport = urlstr[i+1 : len(v.Scheme)]
 		u.Scheme = u.Scheme[:i]
 		checkTransport = true
+	} else if o.transport != "" {
+		u.Transport, checkTransport = o.transport, true
 	}
 	// get dsn generator
-	scheme, ok := schemeMap[u.Scheme]
-	switch {
-	case !ok:
+	scheme, ok := lookupScheme(u.Scheme)
+	if !ok {
 		return nil, ErrUnknownDatabaseScheme
+	}
+	if scheme.Driver != "file" && !scheme.Opaque && u.Opaque != "" {
+		if o.strict && u.isAmbiguous(scheme) {
+			return nil, &AmbiguityError{
+				URL:  urlstr,
+				Host: u.OriginalScheme + "://" + u.buildOpaque(),
+				Path: u.OriginalScheme + ":./" + u.buildOpaque(),
+			}
+		}
+		// scheme does not understand opaque URLs, so normalize in place as
+		// a fully qualified URL
+		if err := u.normalizeOpaque(); err != nil {
+			return nil, err
+		}
+	}
+	switch {
 	case scheme.Driver == "file":
 		// determine scheme for file
 		s := u.opaqueOrPath()
@@ -133,15 +260,13 @@ func Parse(urlstr string) (*URL, error) {
 		case s == "":
 			return nil, ErrMissingPath
 		case ResolveSchemeType:
-			if typ, err := SchemeType(s); err == nil {
-				return Parse(typ + "://" + u.buildOpaque())
+			if typ, err := o.schemeType(s); err == nil {
+				return parse(typ+"://"+u.buildOpaque(), o)
 			}
 		}
 		return nil, ErrUnknownFileExtension
-	case !scheme.Opaque && u.Opaque != "":
-		// if scheme does not understand opaque URLs, retry parsing after
-		// building fully qualified URL
-		return Parse(u.OriginalScheme + "://" + u.buildOpaque())
+	case scheme.Driver == "memory", scheme.Driver == "tmpfile":
+		return parseMemory(u, scheme, o)
 	case scheme.Opaque && u.Opaque == "":
 		// force Opaque
 		u.Opaque, u.Host, u.Path, u.RawPath = u.Host+u.Path, "", "", ""
@@ -149,18 +274,32 @@ func Parse(urlstr string) (*URL, error) {
 		// force unix proto
 		u.Transport = "unix"
 	}
+	// resolve relative file paths
+	if scheme.Opaque {
+		u.Opaque = o.resolvePath(u.Opaque)
+	}
+	// rewrite host
+	if u.Host != "" {
+		if host, ok := o.rewriteHost(u.Hostname(), u.Port()); ok {
+			u.Host = host
+		}
+	}
 	// check transport
-	if checkTransport || u.Transport != "tcp" {
-		switch {
-		case scheme.Transport == TransportNone:
-			return nil, ErrInvalidTransportProtocol
-		case scheme.Transport&TransportAny != 0 && u.Transport != "",
-			scheme.Transport&TransportTCP != 0 && u.Transport == "tcp",
-			scheme.Transport&TransportUDP != 0 && u.Transport == "udp",
-			scheme.Transport&TransportUnix != 0 && u.Transport == "unix":
-		default:
-			return nil, ErrInvalidTransportProtocol
+	switch {
+	case isSRV(u) && allowsSRV(scheme):
+		// host resolved via srv records when opened
+		if u.Host == "" {
+			return nil, ErrMissingHost
 		}
+		u.Transport = "tcp"
+	case checkTransport || u.Transport != "tcp":
+		if !scheme.Transport.Allows(u.Transport) {
+			return nil, &TransportError{scheme.Driver, u.Transport, scheme.Transports()}
+		}
+	}
+	// socket specified via query
+	if _, ok := querySocket(u, scheme); ok {
+		u.Transport = "unix"
 	}
 	// set driver
 	u.Driver, u.UnaliasedDriver = scheme.Driver, scheme.Driver
@@ -168,9 +307,26 @@ func Parse(urlstr string) (*URL, error) {
 		u.Driver = scheme.Override
 	}
 	// generate dsn
-	if u.DSN, u.GoDriver, err = scheme.Generator(u); err != nil {
+	if o.classify {
+		u.diagnose(scheme)
+		incStat(StatParse, scheme.Driver)
+		return u, nil
+	}
+	rawQuery, user := u.RawQuery, u.User
+	if err := translateParams(u, scheme); err != nil {
 		return nil, err
 	}
+	applyTransportParam(u, scheme)
+	if user == nil && scheme.DefaultUser != "" && !o.noDefaults {
+		u.User = url.User(scheme.DefaultUser)
+	}
+	u.DSN, u.GoDriver, err = scheme.generator()(o.ctx, u)
+	u.RawQuery, u.User = rawQuery, user
+	if err != nil {
+		return nil, err
+	}
+	u.diagnose(scheme)
+	incStat(StatParse, scheme.Driver)
 	return u, nil
 }
 
@@ -188,40 +344,186 @@ func Parse(urlstr string) (*URL, error) {
 //	database, dbname, db
 //	instance
 //	parameters, params, options, opts, query, q
+//	fragment
 //
 // See [BuildURL] for more information.
 func FromMap(components map[string]interface{}) (*URL, error) {
-	urlstr, err := BuildURL(components)
+	return FromMapWithOptions(components)
+}
+
+// FromMapWithOptions creates a [URL] using the mapped components and the
+// parse options. When [WithStrict] is specified, the components are built
+// using [BuildURLStrict].
+//
+// See [FromMap] for more information.
+func FromMapWithOptions(components map[string]interface{}, opts ...Option) (*URL, error) {
+	o := newOptions(context.Background(), opts...)
+	urlstr, err := buildURL(components, o.strict)
 	if err != nil {
 		return nil, err
 	}
-	return Parse(urlstr)
+	return parse(urlstr, o)
 }
 
-// String satisfies the [fmt.Stringer] interface.
+// String satisfies the [fmt.Stringer] and [flag.Value] interfaces.
+//
+// The returned string is stable: passing it to [Parse] yields a [URL] with
+// the same Driver, Transport, and DSN, and an identical String.
 func (u *URL) String() string {
 	p := &url.URL{
-		Scheme:   u.OriginalScheme,
-		Opaque:   u.Opaque,
-		User:     u.User,
-		Host:     u.Host,
-		Path:     u.Path,
-		RawPath:  u.RawPath,
-		RawQuery: u.RawQuery,
-		Fragment: u.Fragment,
+		Scheme:      u.OriginalScheme,
+		Opaque:      u.Opaque,
+		User:        u.User,
+		Host:        u.Host,
+		Path:        u.Path,
+		RawPath:     u.RawPath,
+		RawQuery:    u.RawQuery,
+		Fragment:    u.Fragment,
+		RawFragment: u.RawFragment,
 	}
 	return p.String()
 }
 
+// Set satisfies the [flag.Value] interface, parsing the URL string with
+// [Parse] and setting the URL's components, Driver, and DSN. Allows a URL to
+// be used directly as a command-line flag:
+//
+//	var u dburl.URL
+//	flag.Var(&u, "db", "database url")
+func (u *URL) Set(urlstr string) error {
+	z, err := Parse(urlstr)
+	if err != nil {
+		return err
+	}
+	*u = *z
+	return nil
+}
+
+// Type returns the flag value type name ("url"), for flag packages requiring
+// it (ie, spf13/pflag).
+func (u *URL) Type() string {
+	return "url"
+}
+
+// OrderedQuery parses RawQuery and returns the query parameters in the order
+// they were specified. Repeated keys are returned as separate pairs.
+//
+// Malformed pairs are discarded, as with [net/url.URL.Query].
+func (u *URL) OrderedQuery() []KV {
+	var kvs []KV
+	for q := u.RawQuery; q != ""; {
+		var kv string
+		kv, q, _ = strings.Cut(q, "&")
+		if kv == "" || strings.Contains(kv, ";") {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		k, err := url.QueryUnescape(k)
+		if err != nil {
+			continue
+		}
+		if v, err = url.QueryUnescape(v); err != nil {
+			continue
+		}
+		kvs = append(kvs, KV{k, v})
+	}
+	return kvs
+}
+
+// Merge returns a new URL with the components not set on the URL (user,
+// password, host, port, database, and individual query parameters) filled
+// from defaults, and the DSN regenerated. The URL's scheme and transport are
+// always retained.
+//
+// Useful for composing a base URL defined in configuration with per
+// environment overrides.
+func (u *URL) Merge(defaults *URL) (*URL, error) {
+	if defaults == nil {
+		defaults = &URL{}
+	}
+	z := u.URL
+	// user and password
+	switch {
+	case z.User == nil:
+		z.User = defaults.User
+	case defaults.User != nil && z.User.Username() == defaults.User.Username():
+		if _, ok := z.User.Password(); !ok {
+			z.User = defaults.User
+		}
+	}
+	// host and port
+	if z.Opaque == "" {
+		switch {
+		case z.Host == "":
+			z.Host = defaults.Host
+		case z.Port() == "" && defaults.Port() != "":
+			z.Host = net.JoinHostPort(z.Hostname(), defaults.Port())
+		}
+		if strings.TrimPrefix(z.Path, "/") == "" && defaults.Opaque == "" {
+			z.Path, z.RawPath = defaults.Path, defaults.RawPath
+		}
+	}
+	// query
+	q := z.Query()
+	for _, kv := range defaults.OrderedQuery() {
+		if _, ok := q[kv.Key]; ok {
+			continue
+		}
+		if z.RawQuery != "" {
+			z.RawQuery += "&"
+		}
+		z.RawQuery += url.QueryEscape(kv.Key) + "=" + url.QueryEscape(kv.Value)
+	}
+	o := u.opts
+	if o == nil {
+		o = newOptions(context.Background())
+	}
+	return parse((&URL{URL: z, OriginalScheme: u.OriginalScheme}).String(), o)
+}
+
+// ignoreQueryPrefixes returns the query prefixes to ignore when generating
+// ODBC style DSNs, combining the package level [OdbcIgnoreQueryPrefixes], the
+// scheme's IgnoreQueryPrefixes, and the parse options.
+func (u *URL) ignoreQueryPrefixes() []string {
+	prefixes := append([]string(nil), OdbcIgnoreQueryPrefixes...)
+	if scheme, ok := lookupScheme(u.Scheme); ok {
+		prefixes = append(prefixes, scheme.IgnoreQueryPrefixes...)
+	}
+	if u.opts != nil {
+		prefixes = append(prefixes, u.opts.ignoreQueryPrefixes...)
+	}
+	return prefixes
+}
+
+// defaults returns true when implicit hosts, ports, and users should be
+// added to generated DSNs. See [WithoutDefaults].
+func (u *URL) defaults() bool {
+	return u.opts == nil || !u.opts.noDefaults
+}
+
+// queryOrder returns the key order to use when generating order-sensitive
+// DSNs, with the passed keys first followed by the keys of the ordered query.
+// Returns nil (ie, sorted) when not parsed with [WithQueryOrder].
+func (u *URL) queryOrder(keys ...string) []string {
+	if u.opts == nil || !u.opts.queryOrder {
+		return nil
+	}
+	for _, kv := range u.OrderedQuery() {
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
 // Short provides a short description of the user, host, and database.
 func (u *URL) Short() string {
 	if u.Scheme == "" {
 		return ""
 	}
-	s := schemeMap[u.Scheme].Aliases[0]
-	if u.Scheme == "odbc" || u.Scheme == "oleodbc" {
+	scheme, _ := lookupScheme(u.Scheme)
+	s := scheme.Aliases[0]
+	if scheme.Transport&TransportNamed != 0 {
 		n := u.Transport
-		if v, ok := schemeMap[n]; ok {
+		if v, ok := lookupScheme(n); ok {
 			n = v.Aliases[0]
 		}
 		s += "+" + n
@@ -243,13 +545,70 @@ func (u *URL) Short() string {
 	if u.Opaque != "" {
 		s += u.Opaque
 	}
+	if port := u.HTTPPort(); port != "" {
+		s += "?" + httpPortParam + "=" + port
+	}
 	return s
 }
 
+// httpPortParam is the query parameter specifying the port of the database's
+// HTTP API, overriding the scheme's HTTPPort.
+const httpPortParam = "http_port"
+
+// HTTPPort returns the port of the database's HTTP API, from the URL's
+// "http_port" query parameter, or the scheme's HTTPPort (ie, "8030" for the
+// Apache Doris and StarRocks frontend), if any.
+func (u *URL) HTTPPort() string {
+	if port := u.Query().Get(httpPortParam); port != "" {
+		return port
+	}
+	if scheme, ok := lookupScheme(u.UnaliasedDriver); ok {
+		return scheme.HTTPPort
+	}
+	return ""
+}
+
+// RequiresCGO returns true when the Go SQL driver used to open the URL
+// requires CGO (ie, "sqlite3", or a scheme overriding it), and is unavailable
+// in pure Go builds. See [URL.PureGoAlternative].
+func (u *URL) RequiresCGO() bool {
+	if scheme := u.goDriverScheme(); scheme != nil {
+		return scheme.RequiresCGO
+	}
+	return false
+}
+
+// PureGoAlternative returns the scheme of a pure Go driver for the URL's
+// database (ie, "moderncsqlite" for "sqlite3", or "oracle" for "godror"),
+// when the URL's Go SQL driver requires CGO, if any.
+func (u *URL) PureGoAlternative() string {
+	if scheme := u.goDriverScheme(); scheme != nil && scheme.RequiresCGO {
+		return scheme.PureGoAlternative
+	}
+	return ""
+}
+
+// goDriverScheme returns the registered scheme for the Go SQL driver used to
+// open the URL, or the URL's scheme when the Go SQL driver is not a
+// registered scheme.
+func (u *URL) goDriverScheme() *Scheme {
+	for _, name := range []string{u.GoDriver, u.Driver, u.UnaliasedDriver} {
+		if scheme, ok := lookupScheme(name); name != "" && ok {
+			return scheme
+		}
+	}
+	return nil
+}
+
 // Normalize returns the driver, host, port, database, and user name of a URL,
-// joined with sep, populating blank fields with empty.
+// joined with sep, populating blank fields with empty. For schemes with an
+// HTTP API port (see [URL.HTTPPort]), the HTTP port is included as an
+// additional field.
 func (u *URL) Normalize(sep, empty string, cut int) string {
 	s := []string{u.UnaliasedDriver, "", "", "", ""}
+	if port := u.HTTPPort(); port != "" {
+		s = append(s, port)
+	}
 	if u.Transport != "tcp" && u.Transport != "unix" {
 		s[0] += "+" + u.Transport
 	}
@@ -302,6 +661,44 @@ func (u *URL) buildOpaque() string {
 	return up + u.opaqueOrPath() + q + f
 }
 
+// normalizeOpaque normalizes the URL's opaque component (ie, the
+// "user@host/db" of "pg:user@host/db") as the user info, host, and path of a
+// fully qualified URL.
+func (u *URL) normalizeOpaque() error {
+	s := u.Opaque
+	// abstract unix socket (ie, "my+unix:@mysqld/db" or
+	// "my+unix:user@@mysqld/db"), which would otherwise be parsed as user
+	// info, so normalize as an empty host
+	if u.Transport == "unix" {
+		first, _, _ := strings.Cut(s, "/")
+		switch i := strings.Index(first, "@@"); {
+		case strings.HasPrefix(s, "@"):
+			s = "/" + s
+		case i != -1:
+			s = s[:i+1] + "/" + s[i+1:]
+		}
+	}
+	v, err := url.Parse("//" + s)
+	if err != nil {
+		if e, ok := err.(*url.Error); ok {
+			err = e.Err
+		}
+		return &url.Error{Op: "parse", URL: u.OriginalScheme + "://" + u.buildOpaque(), Err: err}
+	}
+	u.Opaque, u.User, u.Host, u.Path, u.RawPath = "", v.User, v.Host, v.Path, v.RawPath
+	return nil
+}
+
+// isAmbiguous returns true when the URL's opaque component could be either a
+// host or a relative path to a unix socket for the scheme (ie, "pg:host:5432").
+func (u *URL) isAmbiguous(scheme *Scheme) bool {
+	if scheme.Transport&TransportUnix == 0 || strings.Contains(u.Opaque, "@") {
+		return false
+	}
+	first, _, _ := strings.Cut(u.Opaque, "/")
+	return first != "." && first != ".."
+}
+
 // opaqueOrPath returns the opaque or path value.
 func (u *URL) opaqueOrPath() string {
 	if u.Opaque != "" {
@@ -312,13 +709,19 @@ func (u *URL) opaqueOrPath() string {
 
 // SchemeType returns the scheme type for a path.
 func SchemeType(name string) (string, error) {
+	return probeType(name, nil)
+}
+
+// probeType returns the scheme type for a path, using the options' file
+// system funcs.
+func probeType(name string, o *options) (string, error) {
 	// try to resolve the path on unix systems
 	if runtime.GOOS != "windows" {
-		if typ, ok := resolveType(name); ok {
+		if typ, ok := resolveType(name, o); ok {
 			return typ, nil
 		}
 	}
-	if f, err := OpenFile(name); err == nil {
+	if f, err := o.open(name); err == nil {
 		defer f.Close()
 		// file exists, match header
 		buf := make([]byte, 64)
@@ -333,6 +736,11 @@ func SchemeType(name string) (string, error) {
 		return "", ErrUnknownFileHeader
 	}
 	// doesn't exist, match file extension
+	return extType(name)
+}
+
+// extType returns the scheme type for a path's file extension.
+func extType(name string) (string, error) {
 	ext := filepath.Ext(name)
 	for _, typ := range fileTypes {
 		if typ.ext.MatchString(ext) {
@@ -372,8 +780,259 @@ const (
 	ErrMissingUser Error = "missing user"
 	// ErrInvalidQuery is the invalid query error.
 	ErrInvalidQuery Error = "invalid query"
+	// ErrNoNetworkAddress is the no network address error.
+	ErrNoNetworkAddress Error = "no network address"
+	// ErrUnsupportedParameter is the unsupported parameter error.
+	ErrUnsupportedParameter Error = "unsupported parameter"
+	// ErrInvalidParameter is the invalid parameter error.
+	ErrInvalidParameter Error = "invalid parameter"
+	// ErrAmbiguousURL is the ambiguous URL error.
+	ErrAmbiguousURL Error = "ambiguous url"
+	// ErrUnknownDriverPackage is the unknown driver package error.
+	ErrUnknownDriverPackage Error = "unknown driver package"
+	// ErrUnsupportedQuery is the unsupported query error.
+	ErrUnsupportedQuery Error = "unsupported query"
+	// ErrUnknownComponent is the unknown component error.
+	ErrUnknownComponent Error = "unknown component"
+	// ErrUnsupportedClient is the unsupported client error.
+	ErrUnsupportedClient Error = "unsupported client"
+	// ErrIncompatibleScheme is the incompatible scheme error.
+	ErrIncompatibleScheme Error = "incompatible scheme"
+	// ErrUntranslatableParameter is the untranslatable parameter error.
+	ErrUntranslatableParameter Error = "untranslatable parameter"
+	// ErrNoSRVRecords is the no srv records error.
+	ErrNoSRVRecords Error = "no srv records"
+	// ErrMissingTLSConfigFunc is the missing tls config func error.
+	ErrMissingTLSConfigFunc Error = "missing tls config func"
+	// ErrMissingCertFile is the missing certificate file error.
+	ErrMissingCertFile Error = "missing certificate file"
+	// ErrInvalidKeyPair is the invalid certificate key pair error.
+	ErrInvalidKeyPair Error = "invalid certificate key pair"
+	// ErrUnsupportedDSN is the unsupported dsn error.
+	ErrUnsupportedDSN Error = "unsupported dsn"
+	// ErrInvalidDSN is the invalid dsn error.
+	ErrInvalidDSN Error = "invalid dsn"
+	// ErrInsecureConnection is the insecure connection error.
+	ErrInsecureConnection Error = "insecure connection"
+	// ErrUnavailableDriver is the unavailable driver error.
+	ErrUnavailableDriver Error = "unavailable driver"
 )
 
+// TransportError is a invalid transport protocol error.
+type TransportError struct {
+	// Scheme is the scheme's driver name.
+	Scheme string
+	// Transport is the invalid transport.
+	Transport string
+	// Allowed are the allowed transports for the scheme.
+	Allowed []string
+}
+
+// Error satisfies the error interface.
+func (err *TransportError) Error() string {
+	if len(err.Allowed) == 0 {
+		return fmt.Sprintf("%s: %s does not support a transport", ErrInvalidTransportProtocol, err.Scheme)
+	}
+	return fmt.Sprintf("%s: %s supports %s, not %q", ErrInvalidTransportProtocol, err.Scheme, strings.Join(err.Allowed, ", "), err.Transport)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *TransportError) Unwrap() error {
+	return ErrInvalidTransportProtocol
+}
+
+// AmbiguityError is an ambiguous URL error, returned when parsing with
+// [WithStrict] and a URL's opaque component could be interpreted as either a
+// host or a path (ie, "pg:host:5432").
+type AmbiguityError struct {
+	// URL is the ambiguous URL.
+	URL string
+	// Host is the URL interpreted with a host (ie, "pg://host:5432").
+	Host string
+	// Path is the URL interpreted with a path (ie, "pg:./host:5432").
+	Path string
+}
+
+// Error satisfies the error interface.
+func (err *AmbiguityError) Error() string {
+	return fmt.Sprintf("%s: %q could be either %q (host) or %q (path)", ErrAmbiguousURL, err.URL, err.Host, err.Path)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *AmbiguityError) Unwrap() error {
+	return ErrAmbiguousURL
+}
+
+// PackageError is an unknown driver package error, returned by
+// [DriverPackages] when the Go SQL driver package for a URL's driver is not
+// known.
+type PackageError struct {
+	// Driver is the scheme's driver name.
+	Driver string
+}
+
+// Error satisfies the error interface.
+func (err *PackageError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnknownDriverPackage, err.Driver)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *PackageError) Unwrap() error {
+	return ErrUnknownDriverPackage
+}
+
+// DriverError is an unavailable driver error, returned by [OpenURL] when the
+// URL's Go SQL driver requires CGO, and is not available (ie, in a pure Go
+// build).
+type DriverError struct {
+	// Driver is the Go SQL driver name.
+	Driver string
+	// Alternative is the scheme of the available pure Go alternative driver
+	// (ie, "moderncsqlite" for "sqlite3"), if any. See [AllowFallbackDrivers].
+	Alternative string
+}
+
+// Error satisfies the error interface.
+func (err *DriverError) Error() string {
+	if err.Alternative != "" {
+		return fmt.Sprintf("%s: %s requires cgo (use %s)", ErrUnavailableDriver, err.Driver, err.Alternative)
+	}
+	return fmt.Sprintf("%s: %s requires cgo", ErrUnavailableDriver, err.Driver)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *DriverError) Unwrap() error {
+	return ErrUnavailableDriver
+}
+
+// OpenError is an error building, parsing, or opening a URL from components,
+// returned by [OpenMap] and [OpenComponents].
+type OpenError struct {
+	// Op is the failed operation ("build", "parse", or "open").
+	Op string
+	// URL is the redacted URL, when parsed.
+	URL string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *OpenError) Error() string {
+	if err.URL != "" {
+		return fmt.Sprintf("%s %s: %v", err.Op, err.URL, err.Err)
+	}
+	return fmt.Sprintf("%s: %v", err.Op, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *OpenError) Unwrap() error {
+	return err.Err
+}
+
+// UnknownKeyError is an unknown component key error, returned by
+// [BuildURLStrict].
+type UnknownKeyError struct {
+	// Keys are the unknown keys.
+	Keys []string
+	// Accepted are the accepted keys.
+	Accepted []string
+}
+
+// Error satisfies the error interface.
+func (err *UnknownKeyError) Error() string {
+	return fmt.Sprintf("%s: %s (accepted: %s)", ErrUnknownComponent, strings.Join(err.Keys, ", "), strings.Join(err.Accepted, ", "))
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *UnknownKeyError) Unwrap() error {
+	return ErrUnknownComponent
+}
+
+// CheckError is a health check error, returned by [Check] when a stage
+// fails.
+type CheckError struct {
+	// Stage is the failed stage.
+	Stage CheckStage
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *CheckError) Error() string {
+	return fmt.Sprintf("check %s: %v", err.Stage, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *CheckError) Unwrap() error {
+	return err.Err
+}
+
+// QueryError is an unsupported query error, returned when a URL's driver does
+// not have a query (ie, a version query).
+type QueryError struct {
+	// Driver is the scheme's driver name.
+	Driver string
+	// Name is the query name.
+	Name string
+}
+
+// Error satisfies the error interface.
+func (err *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrUnsupportedQuery, err.Driver, err.Name)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *QueryError) Unwrap() error {
+	return ErrUnsupportedQuery
+}
+
+// TranslateError is a translation error, returned by [Translate].
+type TranslateError struct {
+	// From is the URL's driver name.
+	From string
+	// To is the target scheme's driver name.
+	To string
+	// Params are the untranslatable query parameters, if any.
+	Params []string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *TranslateError) Error() string {
+	s := fmt.Sprintf("translate %s to %s: %v", err.From, err.To, err.Err)
+	if len(err.Params) != 0 {
+		s += ": " + strings.Join(err.Params, ", ")
+	}
+	return s
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *TranslateError) Unwrap() error {
+	return err.Err
+}
+
+// CertError is a client certificate error, returned when a client
+// certificate or key file is missing, or the certificate and key are invalid.
+type CertError struct {
+	// Param is the query parameter ("tls_cert" or "tls_key").
+	Param string
+	// File is the file name.
+	File string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *CertError) Error() string {
+	return fmt.Sprintf("%s %s: %v", err.Param, err.File, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *CertError) Unwrap() error {
+	return err.Err
+}
+
 // Stat is the default stat func.
 //
 // Used internally to stat files, and used when generating the DSNs for
@@ -382,6 +1041,55 @@ var Stat = func(name string) (fs.FileInfo, error) {
 	return fs.Stat(os.DirFS(filepath.Dir(name)), filepath.Base(name))
 }
 
+// SetStatCache enables caching the results of [Stat] for the duration ttl
+// when resolving Unix domain sockets, socket directories, and data source
+// paths. Useful for tools that repeatedly parse URLs referring to the same
+// paths. A ttl of 0 disables (and clears) the cache.
+func SetStatCache(ttl time.Duration) {
+	statCache.Lock()
+	defer statCache.Unlock()
+	statCache.ttl, statCache.m = ttl, nil
+}
+
+// statCache is the stat cache.
+var statCache struct {
+	sync.Mutex
+	ttl time.Duration
+	m   map[string]statEntry
+}
+
+// statEntry is a stat cache entry.
+type statEntry struct {
+	fi      fs.FileInfo
+	err     error
+	expires time.Time
+}
+
+// cachedStat calls [Stat], using the stat cache when enabled.
+func cachedStat(name string) (fs.FileInfo, error) {
+	statCache.Lock()
+	ttl := statCache.ttl
+	e, ok := statCache.m[name]
+	statCache.Unlock()
+	now := time.Now()
+	switch {
+	case ttl <= 0:
+		return Stat(name)
+	case ok && now.Before(e.expires):
+		return e.fi, e.err
+	}
+	fi, err := Stat(name)
+	statCache.Lock()
+	defer statCache.Unlock()
+	if statCache.ttl == ttl {
+		if statCache.m == nil {
+			statCache.m = make(map[string]statEntry)
+		}
+		statCache.m[name] = statEntry{fi, err, now.Add(ttl)}
+	}
+	return fi, err
+}
+
 // OpenFile is the default open file func.
 //
 // Used internally to read file headers.
@@ -407,12 +1115,54 @@ var OpenFile = func(name string) (fs.File, error) {
 //	database, dbname, db
 //	instance
 //	parameters, params, options, opts, query, q
+//	fragment
 //
 // See [BuildURL] for more information.
 func BuildURL(components map[string]interface{}) (string, error) {
+	return buildURL(components, false)
+}
+
+// BuildURLStrict creates a dsn using the mapped components, returning an
+// [UnknownKeyError] when the components contain an unrecognized key (ie,
+// "prot" instead of "proto").
+//
+// See [BuildURL] for more information.
+func BuildURLStrict(components map[string]interface{}) (string, error) {
+	return buildURL(components, true)
+}
+
+// componentKeys are the recognized component keys.
+var componentKeys = []string{
+	"protocol", "proto", "scheme",
+	"transport",
+	"username", "user",
+	"password", "pass",
+	"hostname", "host",
+	"port",
+	"path", "file", "opaque",
+	"database", "dbname", "db",
+	"instance",
+	"parameters", "params", "options", "opts", "query", "q",
+	"fragment",
+}
+
+// buildURL creates a dsn using the mapped components.
+func buildURL(components map[string]interface{}, strict bool) (string, error) {
 	if components == nil {
 		return "", ErrInvalidDatabaseScheme
 	}
+	if strict {
+		var unknown []string
+		for k := range components {
+			if !slices.Contains(componentKeys, k) {
+				unknown = append(unknown, k)
+			}
+		}
+		if len(unknown) != 0 {
+			slices.Sort(unknown)
+			return "", &UnknownKeyError{Keys: unknown, Accepted: componentKeys}
+		}
+	}
 	var urlstr string
 	if proto, ok := getComponent(components, "protocol", "proto", "scheme"); ok {
 		if transport, ok := getComponent(components, "transport"); ok {
@@ -420,7 +1170,8 @@ func BuildURL(components map[string]interface{}) (string, error) {
 		}
 		urlstr = proto + ":"
 	}
-	if host, ok := getComponent(components, "hostname", "host"); ok {
+	host, hasHost := getComponent(components, "hostname", "host")
+	if hasHost {
 		hostinfo := url.QueryEscape(host)
 		if port, ok := getComponent(components, "port"); ok {
 			hostinfo += ":" + port
@@ -436,8 +1187,11 @@ func BuildURL(components map[string]interface{}) (string, error) {
 		urlstr += "//" + hostinfo
 	}
 	if pathstr, ok := getComponent(components, "path", "file", "opaque"); ok {
-		if urlstr == "" {
+		switch {
+		case urlstr == "":
 			urlstr += "file:"
+		case hasHost && !strings.HasPrefix(pathstr, "/"):
+			pathstr = "/" + pathstr
 		}
 		urlstr += pathstr
 	} else {
@@ -472,13 +1226,40 @@ func BuildURL(components map[string]interface{}) (string, error) {
 			return "", ErrInvalidQuery
 		}
 	}
+	if fragment, ok := getComponent(components, "fragment"); ok {
+		urlstr += "#" + (&url.URL{Fragment: fragment}).EscapedFragment()
+	}
 	return urlstr, nil
 }
 
+// isHostString returns true when s looks like a "[user@]host[:port][/dbname]"
+// string without a scheme, and not a file path.
+func isHostString(s string, o *options) bool {
+	if !hostStringRE.MatchString(s) {
+		return false
+	}
+	// check for registered scheme
+	if i := strings.IndexAny(s, ":/?"); i != -1 && s[i] == ':' {
+		if _, ok := lookupScheme(strings.SplitN(s[:i], "+", 2)[0]); ok {
+			return false
+		}
+	}
+	// check for file
+	if ResolveSchemeType {
+		if _, err := probeType(s, o); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// hostStringRE matches "[user@]host[:port][/dbname][?query]" strings.
+var hostStringRE = regexp.MustCompile(`^(?:[^@/:?\s]+(?::[^@/?\s]*)?@)?(?:\[[0-9a-fA-F:.]+\]|[A-Za-z0-9][A-Za-z0-9._-]*)(?::[0-9]+)?(?:/[^/?\s]*)?(?:\?.*)?$`)
+
 // resolveType tries to resolve a path to a Unix domain socket or directory.
-func resolveType(s string) (string, bool) {
+func resolveType(s string, o *options) (string, bool) {
 	if i := strings.LastIndex(s, "?"); i != -1 {
-		if _, err := Stat(s[:i]); err == nil {
+		if _, err := o.statFile(s[:i]); err == nil {
 			s = s[:i]
 		}
 	}
@@ -489,7 +1270,9 @@ func resolveType(s string) (string, bool) {
 		if i != -1 && i > j {
 			dir = dir[:i]
 		}
-		switch fi, err := Stat(dir); {
+		switch fi, err := o.statFile(dir); {
+		case err == nil && fi.IsDir() && dir == s && isDoltDir(dir, o):
+			return "dolt", true
 		case err == nil && fi.IsDir():
 			return "postgres", true
 		case err == nil && fi.Mode()&fs.ModeSocket != 0:
@@ -506,14 +1289,23 @@ func resolveType(s string) (string, bool) {
 	return "", false
 }
 
+// isDoltDir returns true when dir is a dolt database directory (ie, contains
+// a ".dolt" directory).
+func isDoltDir(dir string, o *options) bool {
+	return mode(path.Join(dir, ".dolt"), o).IsDir()
+}
+
 // resolveSocket tries to resolve a path to a Unix domain socket based on the
 // form "/path/to/socket/dbname" returning either the original path and the
 // empty string, or the components "/path/to/socket" and "dbname", when
 // /path/to/socket/dbname is reported by Stat as a socket.
-func resolveSocket(s string) (string, string) {
+func resolveSocket(s string, o *options) (string, string) {
+	if name, dbname, ok := abstractSocket(s); ok {
+		return name, dbname
+	}
 	dir, dbname := s, ""
 	for dir != "" && dir != "/" && dir != "." {
-		if mode(dir)&fs.ModeSocket != 0 {
+		if mode(dir, o)&fs.ModeSocket != 0 {
 			return dir, dbname
 		}
 		dir, dbname = path.Dir(dir), path.Base(dir)
@@ -522,7 +1314,14 @@ func resolveSocket(s string) (string, string) {
 }
 
 // resolveDir resolves a directory with a :port list.
-func resolveDir(s string) (string, string, string) {
+func resolveDir(s string, o *options) (string, string, string) {
+	if name, dbname, ok := abstractSocket(s); ok {
+		port := ""
+		if i := strings.LastIndex(name, ":"); i != -1 {
+			name, port = name[:i], name[i+1:]
+		}
+		return name, port, dbname
+	}
 	dir := s
 	for dir != "" && dir != "/" && dir != "." {
 		port := ""
@@ -530,7 +1329,7 @@ func resolveDir(s string) (string, string, string) {
 		if i != -1 && i > j {
 			port, dir = dir[i+1:], dir[:i]
 		}
-		if mode(dir)&fs.ModeDir != 0 {
+		if mode(dir, o)&fs.ModeDir != 0 {
 			dbname := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(s, dir), ":"+port), "/")
 			return dir, port, dbname
 		}
@@ -543,9 +1342,22 @@ func resolveDir(s string) (string, string, string) {
 	return s, "", ""
 }
 
+// abstractSocket splits a Linux abstract unix socket name (ie, "@mysqld" or
+// "\x00mysqld") and the remaining path from s. The returned name always uses
+// the "@" form. Abstract sockets are not stat'd, as they do not exist on the
+// filesystem.
+func abstractSocket(s string) (string, string, bool) {
+	s = strings.TrimPrefix(s, "/")
+	if s == "" || (s[0] != '@' && s[0] != 0) {
+		return "", "", false
+	}
+	name, dbname, _ := strings.Cut(s[1:], "/")
+	return "@" + name, dbname, true
+}
+
 // mode returns the mode of the path.
-func mode(s string) os.FileMode {
-	if fi, err := Stat(s); err == nil {
+func mode(s string, o *options) os.FileMode {
+	if fi, err := o.statFile(s); err == nil {
 		return fi.Mode()
 	}
 	return 0
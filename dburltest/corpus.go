@@ -0,0 +1,133 @@
+package dburltest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xo/dburl"
+)
+
+// Case is a corpus test case, checking that a URL parses to the expected
+// driver and DSN.
+type Case struct {
+	// Line is the line the case starts on in the corpus.
+	Line int
+	// URL is the URL to parse.
+	URL string
+	// Driver is the expected driver (or Go driver, when set by the scheme).
+	Driver string
+	// DSN is the expected DSN.
+	DSN string
+	// Path is a path that must exist on disk for the DSN to be checked (ie,
+	// a unix socket). When the path does not exist, a mismatched DSN is only
+	// logged.
+	Path string
+}
+
+// ParseCorpus parses corpus test cases from the reader.
+//
+// A corpus is a text file of cases, where each case is a "url:" line,
+// followed by "driver:", "dsn:", and (optional) "path:" lines. Values are
+// separated from the field name by a single space. Blank lines and lines
+// starting with "#" are ignored:
+//
+//	# postgres
+//	url: pg://user:pass@localhost/mydb
+//	driver: postgres
+//	dsn: dbname=mydb host=localhost password=pass user=user
+func ParseCorpus(r io.Reader) ([]Case, error) {
+	var cases []Case
+	i, s := 0, bufio.NewScanner(r)
+	for s.Scan() {
+		i++
+		line := s.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid line", i)
+		}
+		v = strings.TrimPrefix(v, " ")
+		if k == "url" {
+			cases = append(cases, Case{Line: i, URL: v})
+			continue
+		}
+		if len(cases) == 0 {
+			return nil, fmt.Errorf("line %d: %s before url", i, k)
+		}
+		c := &cases[len(cases)-1]
+		switch k {
+		case "driver":
+			c.Driver = v
+		case "dsn":
+			c.DSN = v
+		case "path":
+			c.Path = v
+		default:
+			return nil, fmt.Errorf("line %d: unknown field %q", i, k)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+// RunCorpus runs the corpus test cases as subtests, named by line number.
+func RunCorpus(t *testing.T, cases []Case) {
+	t.Helper()
+	seen := make(map[string]int)
+	for _, c := range cases {
+		test := c
+		t.Run(strconv.Itoa(test.Line), func(t *testing.T) {
+			if line, ok := seen[test.URL]; ok {
+				t.Fatalf("%s is already tested on line %d", test.URL, line)
+			}
+			seen[test.URL] = test.Line
+			Check(t, test)
+		})
+	}
+}
+
+// RunCorpusFile runs the corpus test cases contained in file.
+func RunCorpusFile(t *testing.T, file string) {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer f.Close()
+	cases, err := ParseCorpus(f)
+	if err != nil {
+		t.Fatalf("%s: expected no error, got: %v", file, err)
+	}
+	RunCorpus(t, cases)
+}
+
+// Check checks that the test case's URL parses to the expected driver and
+// DSN.
+func Check(t testing.TB, c Case) {
+	t.Helper()
+	u, err := dburl.Parse(c.URL)
+	switch {
+	case err != nil:
+		t.Errorf("%q expected no error, got: %v", c.URL, err)
+	case u.GoDriver != "" && u.GoDriver != c.Driver:
+		t.Errorf("%q expected go driver %q, got: %q", c.URL, c.Driver, u.GoDriver)
+	case u.GoDriver == "" && u.Driver != c.Driver:
+		t.Errorf("%q expected driver %q, got: %q", c.URL, c.Driver, u.Driver)
+	case u.DSN != c.DSN:
+		_, err := os.Stat(c.Path)
+		if c.Path != "" && err != nil && os.IsNotExist(err) {
+			t.Logf("%q expected dsn %q, got: %q -- ignoring because `%s` does not exist", c.URL, c.DSN, u.DSN, c.Path)
+		} else {
+			t.Errorf("%q expected:\n%q\ngot:\n%q", c.URL, c.DSN, u.DSN)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package dburltest provides helpers for writing hermetic tests of dburl's
+// URL resolution, such as a fake filesystem for the unix sockets, socket
+// directories, and database files that dburl resolves, and scheme fixtures.
+package dburltest
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xo/dburl"
+)
+
+// File headers.
+const (
+	// SQLite3Header is a SQLite3 database file header.
+	SQLite3Header = "SQLite format 3\000"
+	// DuckDBHeader is a DuckDB database file header.
+	DuckDBHeader = "12345678DUCK"
+)
+
+// FS is a fake filesystem for [dburl.Stat] and [dburl.OpenFile].
+type FS struct {
+	mu    sync.RWMutex
+	files map[string]file
+}
+
+// file is a fake file.
+type file struct {
+	mode    fs.FileMode
+	content []byte
+}
+
+// New creates a new, empty fake filesystem.
+func New() *FS {
+	return &FS{
+		files: make(map[string]file),
+	}
+}
+
+// Socket adds a unix socket (ie, "/var/run/mysqld/mysqld.sock").
+func (f *FS) Socket(name string) *FS {
+	return f.add(name, fs.ModeSocket, nil)
+}
+
+// Dir adds a directory (ie, the "/var/run/postgresql" socket directory).
+func (f *FS) Dir(name string) *FS {
+	return f.add(name, fs.ModeDir|0o755, nil)
+}
+
+// File adds a regular file with the content.
+func (f *FS) File(name string, content []byte) *FS {
+	return f.add(name, 0o644, content)
+}
+
+// SQLite3 adds a SQLite3 database file.
+func (f *FS) SQLite3(name string) *FS {
+	return f.File(name, pad(SQLite3Header))
+}
+
+// DuckDB adds a DuckDB database file.
+func (f *FS) DuckDB(name string) *FS {
+	return f.File(name, pad(DuckDBHeader))
+}
+
+// Remove removes the named file.
+func (f *FS) Remove(name string) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.files, name)
+	return f
+}
+
+// add adds a file.
+func (f *FS) add(name string, mode fs.FileMode, content []byte) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[name] = file{mode, content}
+	return f
+}
+
+// get returns the named file.
+func (f *FS) get(name string) (file, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	z, ok := f.files[name]
+	return z, ok
+}
+
+// Stat satisfies the [dburl.Stat] func signature.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	z, ok := f.get(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return &fileInfo{path.Base(name), z}, nil
+}
+
+// OpenFile satisfies the [dburl.OpenFile] func signature.
+func (f *FS) OpenFile(name string) (fs.File, error) {
+	z, ok := f.get(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &openFile{fileInfo{path.Base(name), z}, bytes.NewReader(z.content)}, nil
+}
+
+// Install installs the fake filesystem as [dburl.Stat] and [dburl.OpenFile]
+// for the duration of the test, restoring the previous funcs on cleanup.
+//
+// When fallback is true, names not in the fake filesystem are passed to the
+// previous funcs. The stat cache is disabled while installed (see
+// [dburl.SetStatCache]).
+func (f *FS) Install(t testing.TB, fallback bool) {
+	t.Helper()
+	stat, open := dburl.Stat, dburl.OpenFile
+	dburl.SetStatCache(0)
+	dburl.Stat = func(name string) (fs.FileInfo, error) {
+		if _, ok := f.get(name); fallback && !ok {
+			return stat(name)
+		}
+		return f.Stat(name)
+	}
+	dburl.OpenFile = func(name string) (fs.File, error) {
+		if _, ok := f.get(name); fallback && !ok {
+			return open(name)
+		}
+		return f.OpenFile(name)
+	}
+	t.Cleanup(func() {
+		dburl.Stat, dburl.OpenFile = stat, open
+	})
+}
+
+// fileInfo is fake file info.
+type fileInfo struct {
+	name string
+	file
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return int64(len(fi.content)) }
+func (fi *fileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi *fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi *fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *fileInfo) Sys() interface{}   { return nil }
+
+// openFile is a fake open file.
+type openFile struct {
+	fi fileInfo
+	*bytes.Reader
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return &f.fi, nil }
+func (f *openFile) Close() error               { return nil }
+
+// pad pads the header, as file type detection reads a fixed size header.
+func pad(header string) []byte {
+	return append([]byte(header), make([]byte, 64)...)
+}
+
+// RegisterScheme registers the scheme for the duration of the test,
+// replacing any registered scheme with the same driver name, and restoring
+// it on cleanup.
+func RegisterScheme(t testing.TB, scheme dburl.Scheme) {
+	t.Helper()
+	prev := dburl.Unregister(scheme.Driver)
+	dburl.Register(scheme)
+	t.Cleanup(func() {
+		dburl.Unregister(scheme.Driver)
+		if prev != nil {
+			dburl.Register(*prev)
+		}
+	})
+}
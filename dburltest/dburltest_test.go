@@ -0,0 +1,68 @@
+package dburltest
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/xo/dburl"
+)
+
+func TestInstall(t *testing.T) {
+	New().
+		Dir("/run/pg").
+		Socket("/run/mysql.sock").
+		SQLite3("/data/app.db").
+		DuckDB("/data/analytics.db").
+		Install(t, false)
+	tests := []struct {
+		s   string
+		exp string
+	}{
+		{`/run/pg:6666/mydb`, `dbname=mydb host=/run/pg port=6666`},
+		{`/run/mysql.sock/mydb`, `unix(/run/mysql.sock)/mydb`},
+		{`/data/app.db`, `/data/app.db`},
+		{`/data/analytics.db`, `/data/analytics.db`},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			u, err := dburl.Parse(test.s)
+			switch {
+			case err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			case u.DSN != test.exp:
+				t.Errorf("expected %q, got: %q", test.exp, u.DSN)
+			}
+		})
+	}
+	u, err := dburl.Parse("/data/analytics.db")
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case u.Driver != "duckdb":
+		t.Errorf("expected duckdb, got: %q", u.Driver)
+	}
+}
+
+func TestRegisterScheme(t *testing.T) {
+	t.Run("register", func(t *testing.T) {
+		RegisterScheme(t, dburl.Scheme{
+			Driver:    "postgres",
+			Generator: dburl.GenFromURL("postgres://localhost:6875/"),
+			Aliases:   []string{"pg"},
+		})
+		u, err := dburl.Parse("pg://user@host/mydb")
+		switch {
+		case err != nil:
+			t.Fatalf("expected no error, got: %v", err)
+		case u.DSN != "postgres://user@host:6875/mydb":
+			t.Errorf("expected replaced scheme, got: %q", u.DSN)
+		}
+	})
+	u, err := dburl.Parse("pg://user@host/mydb")
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case u.DSN != "dbname=mydb host=host user=user":
+		t.Errorf("expected restored scheme, got: %q", u.DSN)
+	}
+}
@@ -0,0 +1,118 @@
+package dburl
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DialAddr returns the network ("tcp", "udp", or "unix") and address of the
+// database server for the URL, along with the TLS config when the URL requests
+// TLS, allowing health checkers to test reachability without opening a SQL
+// session.
+//
+// The TLS config is derived from the URL's query parameters where possible
+// (ie, "sslmode", "sslrootcert", "tls", "encrypt", "TrustServerCertificate").
+// The network is "tcp4" or "tcp6" (or "udp4", "udp6") when the URL's "ip"
+// query parameter is "4" or "6". Returns [ErrNoNetworkAddress] for URLs that do not refer to a network
+// address, such as file based databases or ODBC connections.
+func DialAddr(u *URL) (string, string, *tls.Config, error) {
+	scheme, ok := lookupScheme(u.Scheme)
+	if !ok {
+		return "", "", nil, ErrUnknownDatabaseScheme
+	}
+	if scheme.Opaque {
+		return "", "", nil, ErrNoNetworkAddress
+	}
+	host, port := u.Hostname(), u.Port()
+	if u.hostPortDB != nil {
+		host, port = u.hostPortDB[0], u.hostPortDB[1]
+	}
+	network := u.Transport
+	switch network {
+	case "unix":
+		if host == "" {
+			return "", "", nil, ErrNoNetworkAddress
+		}
+		if family(scheme) == "postgres" {
+			if port == "" {
+				port = scheme.DefaultPort
+			}
+			host += "/.s.PGSQL." + port
+		}
+		return network, host, nil, nil
+	case "http":
+		network, port = "tcp", defaultString(port, "80")
+	case "https":
+		network, port = "tcp", defaultString(port, "443")
+	case "tcp", "udp":
+		port = defaultString(port, scheme.DefaultPort)
+	default:
+		return "", "", nil, ErrNoNetworkAddress
+	}
+	// preferred ip family
+	if s := ipNetwork(u); s != "" {
+		network += strings.TrimPrefix(s, "ip")
+	}
+	if port == "" {
+		return "", "", nil, ErrNoNetworkAddress
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	var tlsConfig *tls.Config
+	if expectsTLS(u) {
+		var err error
+		if tlsConfig, err = dialTLSConfig(u, host); err != nil {
+			return "", "", nil, err
+		}
+	}
+	return network, net.JoinHostPort(host, port), tlsConfig, nil
+}
+
+// dialTLSConfig builds the TLS config for the URL and host.
+func dialTLSConfig(u *URL, host string) (*tls.Config, error) {
+	q := u.Query()
+	tlsConfig, err := buildTLSConfig(q, host)
+	if err != nil {
+		return nil, err
+	}
+	// verification
+	switch {
+	case tlsConfig.InsecureSkipVerify:
+	case q.Get("sslmode") == "require" && q.Get("sslrootcert") == "",
+		strings.EqualFold(q.Get("tls"), "skip-verify"),
+		strings.EqualFold(q.Get("TrustServerCertificate"), "true"):
+		tlsConfig.InsecureSkipVerify = true
+	case q.Get("sslmode") == "verify-ca", q.Get("sslmode") == "require":
+		// verify the certificate chain, but not the host name
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("no peer certificates")
+			}
+			opts := x509.VerifyOptions{
+				Roots:         tlsConfig.RootCAs,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	}
+	return tlsConfig, nil
+}
+
+// defaultString returns the first non-empty string.
+func defaultString(v ...string) string {
+	for _, s := range v {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package dburl
+
+import (
+	"sort"
+	"strings"
+)
+
+// Difference is a component level difference between two URLs. See [Diff].
+type Difference struct {
+	// Component is the component name ("driver", "transport", "user",
+	// "password", "host", "port", "path", "fragment", or "query.<key>" for
+	// query parameters).
+	Component string
+	// Old is the component's value in the first URL, if any.
+	Old string
+	// New is the component's value in the second URL, if any.
+	New string
+}
+
+// Kind returns the kind of difference ("added", "removed", or "changed").
+func (d Difference) Kind() string {
+	switch {
+	case d.Old == "":
+		return "added"
+	case d.New == "":
+		return "removed"
+	}
+	return "changed"
+}
+
+// String satisfies the [fmt.Stringer] interface.
+func (d Difference) String() string {
+	s := d.Component + " " + d.Kind()
+	switch d.Kind() {
+	case "added":
+		return s + ": " + d.New
+	case "removed":
+		return s + ": " + d.Old
+	}
+	return s + ": " + d.Old + " -> " + d.New
+}
+
+// Diff parses the URL strings, returning the component level differences
+// between them (ie, host changed, "sslmode" added), for detecting
+// configuration drift. Passwords and secret query parameters (ie, "token")
+// are redacted, and are reported as changed as "xxxxx".
+//
+// Scheme aliases of the same driver (ie, "pg" and "postgres") are not
+// reported as different.
+func Diff(a, b string) ([]Difference, error) {
+	u, err := Parse(a)
+	if err != nil {
+		return nil, err
+	}
+	v, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	x, y := diffComponents(u), diffComponents(v)
+	var keys []string
+	for k := range x {
+		keys = append(keys, k)
+	}
+	for k := range y {
+		if _, ok := x[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return diffOrder(keys[i]) < diffOrder(keys[j]) ||
+			diffOrder(keys[i]) == diffOrder(keys[j]) && keys[i] < keys[j]
+	})
+	var diffs []Difference
+	for _, k := range keys {
+		if x[k] == y[k] {
+			continue
+		}
+		d := Difference{Component: k, Old: x[k], New: y[k]}
+		if isSecretComponent(k) {
+			if d.Old != "" {
+				d.Old = redacted
+			}
+			if d.New != "" {
+				d.New = redacted
+			}
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
+
+// diffComponentNames are the compared component names, in order.
+var diffComponentNames = []string{"driver", "transport", "user", "password", "host", "port", "path", "fragment"}
+
+// diffOrder returns the sort order for a component name.
+func diffOrder(name string) int {
+	for i, s := range diffComponentNames {
+		if s == name {
+			return i
+		}
+	}
+	return len(diffComponentNames)
+}
+
+// diffComponents returns the non-empty components of the URL.
+func diffComponents(u *URL) map[string]string {
+	m := map[string]string{
+		"driver":    u.UnaliasedDriver,
+		"transport": u.Transport,
+		"host":      u.Hostname(),
+		"port":      u.Port(),
+		"path":      u.opaqueOrPath(),
+		"fragment":  u.Fragment,
+	}
+	if u.User != nil {
+		m["user"] = u.User.Username()
+		m["password"], _ = u.User.Password()
+	}
+	for k, v := range u.Query() {
+		m["query."+k] = strings.Join(v, ",")
+	}
+	for k, v := range m {
+		if v == "" {
+			delete(m, k)
+		}
+	}
+	return m
+}
+
+// isSecretComponent returns true when the component contains a secret.
+func isSecretComponent(name string) bool {
+	if name == "password" {
+		return true
+	}
+	key, ok := strings.CutPrefix(name, "query.")
+	return ok && contains(secretKeys, strings.ToLower(key))
+}
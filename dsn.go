@@ -2,14 +2,20 @@ package dburl
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"path"
-	"sort"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 )
 
-// OdbcIgnoreQueryPrefixes are the query prefixes to ignore when generating the
-// odbc DSN. Used by GenOdbc
+// OdbcIgnoreQueryPrefixes are the query prefixes to ignore when generating ODBC
+// style DSNs. Used by GenAdodb, GenCosmos, GenDynamo, and GenOdbc.
+//
+// Deprecated: use the [WithIgnoreQueryPrefixes] parse option, or set the
+// Scheme's IgnoreQueryPrefixes when registering.
 var OdbcIgnoreQueryPrefixes []string
 
 // GenScheme returns a generator that will generate a scheme based on the
@@ -26,38 +32,77 @@ func GenScheme(scheme string) func(*URL) (string, string, error) {
 			RawQuery: u.RawQuery,
 			Fragment: u.Fragment,
 		}
-		if z.Host == "" {
+		if z.Host == "" && u.defaults() {
 			z.Host = "localhost"
 		}
 		return z.String(), "", nil
 	}
 }
 
+// QueryMerge is the policy used when merging a generator's default query
+// parameters with query parameters of the same key in a parsed [URL].
+type QueryMerge uint
+
+// QueryMerge policies.
+const (
+	// QueryMergeReplace replaces the default values with the URL's values.
+	QueryMergeReplace QueryMerge = iota
+	// QueryMergeAppend appends the URL's values after the default values.
+	QueryMergeAppend
+	// QueryMergeKeep keeps the default values, discarding the URL's values.
+	QueryMergeKeep
+)
+
 // GenFromURL returns a func that generates a DSN based on parameters of the
 // passed URL.
+//
+// Query parameters in the parsed URL replace default query parameters of the
+// same key. See [GenFromURLMerge] to use a different policy.
 func GenFromURL(urlstr string) func(*URL) (string, string, error) {
-	z, err := url.Parse(urlstr)
+	return GenFromURLMerge(urlstr, QueryMergeReplace)
+}
+
+// GenFromURLMerge returns a func that generates a DSN based on parameters of
+// the passed URL, merging the default query parameters with the parsed URL's
+// query parameters using the merge policy.
+func GenFromURLMerge(urlstr string, merge QueryMerge) func(*URL) (string, string, error) {
+	f, err := genFromURL(urlstr, merge)
 	if err != nil {
 		panic(err)
 	}
+	return f
+}
+
+// genFromURL returns a func that generates a DSN based on parameters of the
+// passed URL.
+func genFromURL(urlstr string, merge QueryMerge) (func(*URL) (string, string, error), error) {
+	z, err := url.Parse(urlstr)
+	if err != nil {
+		return nil, err
+	}
 	return func(u *URL) (string, string, error) {
 		opaque := z.Opaque
 		if u.Opaque != "" {
 			opaque = u.Opaque
 		}
-		user := z.User
+		user, host, port := z.User, z.Hostname(), z.Port()
+		if !u.defaults() {
+			user, host, port = nil, "", ""
+		}
 		if u.User != nil {
 			user = u.User
 		}
-		host, port := z.Hostname(), z.Port()
-		if h := u.Hostname(); h != "" {
-			host = h
-		}
-		if p := u.Port(); p != "" {
-			port = p
-		}
-		if port != "" {
-			host += ":" + port
+		switch {
+		case strings.Contains(u.Host, ","):
+			// multiple hosts (ie, "host1:5432,host2"), using the default port
+			// for hosts without a port
+			hosts, ports := splitHosts(u.Host)
+			for i := range ports {
+				ports[i] = defaultString(ports[i], port)
+			}
+			host = joinHosts(hosts, ports)
+		default:
+			host = joinHostPort(defaultString(u.Hostname(), host), defaultString(u.Port(), port))
 		}
 		pstr := z.Path
 		if u.Path != "" {
@@ -67,10 +112,7 @@ func GenFromURL(urlstr string) func(*URL) (string, string, error) {
 		if u.RawPath != "" {
 			rawPath = u.RawPath
 		}
-		q := z.Query()
-		for k, v := range u.Query() {
-			q.Set(k, strings.Join(v, " "))
-		}
+		q := mergeQuery(z.Query(), u.Query(), merge)
 		fragment := z.Fragment
 		if u.Fragment != "" {
 			fragment = u.Fragment
@@ -86,15 +128,64 @@ func GenFromURL(urlstr string) func(*URL) (string, string, error) {
 			Fragment: fragment,
 		}
 		return strings.TrimPrefix(y.String(), "truncate://"), "", nil
-	}
+	}, nil
 }
 
-// GenOpaque generates a opaque file path DSN from the passed URL.
+// GenOpaque generates a opaque file path DSN from the passed URL. The raw
+// query and fragment are passed through as-is, as some drivers treat the DSN
+// literally.
 func GenOpaque(u *URL) (string, string, error) {
 	if u.Opaque == "" {
 		return "", "", ErrMissingPath
 	}
-	return u.Opaque + genQueryOptions(u.Query()), "", nil
+	return genOpaque(u, u.RawQuery), "", nil
+}
+
+// genOpaque generates a opaque file path DSN from the passed URL with the raw
+// query, appending the URL's fragment, if any.
+func genOpaque(u *URL, rawQuery string) string {
+	dsn := opaquePath(u.Opaque)
+	if rawQuery != "" {
+		dsn += "?" + rawQuery
+	}
+	if u.Fragment != "" {
+		dsn += "#" + u.EscapedFragment()
+	}
+	return dsn
+}
+
+// stripRawQuery returns the raw query without the keys, leaving the remaining
+// parameters in their original order and encoding.
+func stripRawQuery(rawQuery string, keys ...string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var v []string
+	for _, s := range strings.Split(rawQuery, "&") {
+		k, _, _ := strings.Cut(s, "=")
+		if z, err := url.QueryUnescape(k); err == nil {
+			k = z
+		}
+		if s != "" && !slices.Contains(keys, k) {
+			v = append(v, s)
+		}
+	}
+	return strings.Join(v, "&")
+}
+
+// opaquePath returns the opaque path, removing the leading "/" of Windows
+// drive letter paths (ie, "/C:/data/file.db" from "sq:///C:/data/file.db").
+func opaquePath(s string) string {
+	if len(s) > 1 && s[0] == '/' && isDrivePath(s[1:]) {
+		return s[1:]
+	}
+	return s
+}
+
+// isDrivePath returns true when the path starts with a Windows drive letter
+// (ie, "C:/data/file.db", `C:\data\file.db`).
+func isDrivePath(s string) bool {
+	return len(s) > 2 && ('a' <= s[0]|0x20 && s[0]|0x20 <= 'z') && s[1] == ':' && (s[2] == '/' || s[2] == '\\')
 }
 
 // GenAdodb generates a adodb DSN from the passed URL.
@@ -106,7 +197,7 @@ func GenAdodb(u *URL) (string, string, error) {
 		dsname = "."
 	}
 	// check if data source is not a path on disk
-	if mode(dsname) == 0 {
+	if mode(dsname, u.opts) == 0 {
 		if i := strings.IndexAny(dsname, `\/`); i != -1 {
 			dbname = dsname[i+1:]
 			dsname = dsname[:i]
@@ -114,14 +205,15 @@ func GenAdodb(u *URL) (string, string, error) {
 	}
 	// build q
 	q := u.Query()
+	foldKeys(q, adodbKeys)
 	q.Set("Provider", host)
 	q.Set("Port", port)
 	q.Set("Data Source", dsname)
 	q.Set("Database", dbname)
+	var empty []string
 	if u.User != nil {
 		q.Set("User ID", u.User.Username())
-		pass, _ := u.User.Password()
-		q.Set("Password", pass)
+		empty = setPassword(q, "Password", u.User)
 	}
 	if u.hostPortDB == nil {
 		n := dsname
@@ -130,23 +222,39 @@ func GenAdodb(u *URL) (string, string, error) {
 		}
 		u.hostPortDB = []string{host, port, n}
 	}
-	return genOptionsOdbc(q, true, nil, OdbcIgnoreQueryPrefixes), "", nil
+	order := u.queryOrder("Provider", "Port", "Data Source", "Database", "User ID", "Password")
+	return genOptionsOdbc(q, true, nil, u.ignoreQueryPrefixes(), order, empty...), "", nil
 }
 
 // GenCassandra generates a cassandra DSN from the passed URL.
+//
+// Multiple contact points may be specified as a comma separated host list
+// (ie, "ca://h1,h2,h3:9043/keyspace"), with the port of the last host
+// applied to each host without a port. The
+// "consistency", "datacenter", "num_conns", "disable_initial_host_lookup",
+// "ca_path", "cert_path", and "key_path" query parameters are validated and
+// passed as the driver's equivalent options.
 func GenCassandra(u *URL) (string, string, error) {
-	host, port, dbname := "localhost", "9042", strings.TrimPrefix(u.Path, "/")
-	if h := u.Hostname(); h != "" {
-		host = h
-	}
-	if p := u.Port(); p != "" {
-		port = p
+	hosts, ports := splitHosts(u.Host)
+	for i, h := range hosts {
+		ports[i] = defaultString(ports[i], ports[len(ports)-1])
+		if u.defaults() {
+			h, ports[i] = defaultString(h, "localhost"), defaultString(ports[i], "9042")
+		}
+		if h == "" {
+			return "", "", ErrMissingHost
+		}
+		hosts[i] = joinHostPort(h, ports[i])
 	}
+	dbname := strings.TrimPrefix(u.Path, "/")
 	q := u.Query()
+	if err := cassandraOptions(u, q); err != nil {
+		return "", "", err
+	}
 	// add user/pass
 	if u.User != nil {
 		q.Set("username", u.User.Username())
-		if pass, _ := u.User.Password(); pass != "" {
+		if pass, ok := u.User.Password(); ok {
 			q.Set("password", pass)
 		}
 	}
@@ -154,7 +262,56 @@ func GenCassandra(u *URL) (string, string, error) {
 	if dbname != "" {
 		q.Set("keyspace", dbname)
 	}
-	return host + ":" + port + genQueryOptions(q), "", nil
+	return strings.Join(hosts, ",") + genQueryOptions(q), "", nil
+}
+
+// cassandraOptions validates and renames the cassandra query options.
+func cassandraOptions(u *URL, q url.Values) error {
+	for _, k := range []string{"consistency", "datacenter", "num_conns", "disable_initial_host_lookup", "ca_path", "cert_path", "key_path"} {
+		if !q.Has(k) {
+			continue
+		}
+		v := q.Get(k)
+		name := k
+		switch k {
+		case "consistency":
+			v = strings.ToUpper(v)
+			if !contains(cassandraConsistencies, v) {
+				return &ParamError{u.UnaliasedDriver, k, q.Get(k), ErrInvalidParameter}
+			}
+		case "datacenter":
+			name = "dataCenter"
+		case "num_conns":
+			if n, err := strconv.Atoi(v); err != nil || n < 1 {
+				return &ParamError{u.UnaliasedDriver, k, v, ErrInvalidParameter}
+			}
+			name = "numConns"
+		case "disable_initial_host_lookup":
+			b, err := strconv.ParseBool(defaultString(v, "true"))
+			if err != nil {
+				return &ParamError{u.UnaliasedDriver, k, v, ErrInvalidParameter}
+			}
+			name, v = "disableInitialHostLookup", strconv.FormatBool(b)
+		case "ca_path":
+			name = "caPath"
+		case "cert_path":
+			name = "certPath"
+		case "key_path":
+			name = "keyPath"
+		}
+		if v == "" {
+			return &ParamError{u.UnaliasedDriver, k, v, ErrInvalidParameter}
+		}
+		q.Del(k)
+		q.Set(name, v)
+	}
+	return nil
+}
+
+// cassandraConsistencies are the cassandra consistency levels.
+var cassandraConsistencies = []string{
+	"ANY", "ONE", "TWO", "THREE", "QUORUM", "ALL",
+	"LOCAL_QUORUM", "EACH_QUORUM", "LOCAL_ONE",
 }
 
 // GenClickhouse generates a clickhouse DSN from the passed URL.
@@ -180,11 +337,9 @@ var (
 // GenCosmos generates a cosmos DSN from the passed URL.
 func GenCosmos(u *URL) (string, string, error) {
 	host, port, dbname := u.Hostname(), u.Port(), strings.TrimPrefix(u.Path, "/")
-	if port != "" {
-		port = ":" + port
-	}
 	q := u.Query()
-	q.Set("AccountEndpoint", "https://"+host+port)
+	foldKeys(q, cosmosKeys)
+	q.Set("AccountEndpoint", "https://"+joinHostPort(host, port))
 	// add user/pass
 	if u.User == nil {
 		return "", "", ErrMissingUser
@@ -193,7 +348,7 @@ func GenCosmos(u *URL) (string, string, error) {
 	if dbname != "" {
 		q.Set("Db", dbname)
 	}
-	return genOptionsOdbc(q, true, nil, nil), "", nil
+	return genOptionsOdbc(q, true, nil, u.ignoreQueryPrefixes(), u.queryOrder("AccountEndpoint", "AccountKey", "Db")), "", nil
 }
 
 // GenDatabend generates a databend DSN from the passed URL.
@@ -216,7 +371,44 @@ func GenDynamo(u *URL) (string, string, error) {
 			v = append(v, "Secret_Key="+pass)
 		}
 	}
-	return strings.Join(v, ";") + genOptions(u.Query(), ";", "=", ";", ",", true, []string{"Region", "Secret_Key", "AkId"}, nil), "", nil
+	return strings.Join(v, ";") + genOptions(u.Query(), ";", "=", ";", ",", escapeNone, true, []string{"Region", "Secret_Key", "AkId"}, u.ignoreQueryPrefixes()), "", nil
+}
+
+// GenD1 generates a Cloudflare D1 DSN from the passed URL, of the form
+// "d1://[user:]<api token>@<account id>/<database id>", as the D1 HTTP API
+// endpoint for the database with the API token passed as the "token" query
+// parameter.
+func GenD1(u *URL) (string, string, error) {
+	account, database := u.Hostname(), strings.TrimPrefix(u.Path, "/")
+	switch {
+	case account == "":
+		return "", "", ErrMissingHost
+	case database == "":
+		return "", "", ErrMissingPath
+	}
+	q := u.Query()
+	if token := userToken(u); token != "" {
+		q.Set("token", token)
+	}
+	z := &url.URL{
+		Scheme:   "https",
+		Host:     "api.cloudflare.com",
+		Path:     "/client/v4/accounts/" + account + "/d1/database/" + database,
+		RawQuery: q.Encode(),
+	}
+	return z.String(), "", nil
+}
+
+// userToken returns the URL's password, or the user name when no password
+// is specified, for use as an API token.
+func userToken(u *URL) string {
+	if u.User == nil {
+		return ""
+	}
+	if pass, ok := u.User.Password(); ok {
+		return pass
+	}
+	return u.User.Username()
 }
 
 // GenDatabricks generates a databricks DSN from the passed URL.
@@ -237,28 +429,49 @@ func GenDatabricks(u *URL) (string, string, error) {
 		port = "443"
 	}
 	s := fmt.Sprintf("token:%s@%s.databricks.com:%s/sql/1.0/endpoints/%s", user, pass, port, host)
-	return s + genOptions(u.Query(), "?", "=", "&", ",", true, nil, nil), "", nil
+	return s + genOptions(u.Query(), "?", "=", "&", ",", escapePercent, true, nil, nil), "", nil
+}
+
+// GenDolt generates a dolt DSN from the passed URL.
+//
+// When the URL's path is a directory (ie, "dolt:/path/to/dbs"), generates a
+// "file://" DSN for the embedded dolt driver. Otherwise, generates a mysql
+// DSN for a dolt sql-server, using the mysql driver.
+func GenDolt(u *URL) (string, string, error) {
+	if u.Transport == "unix" && u.Host == "" && mode(u.Path, u.opts).IsDir() {
+		if u.hostPortDB == nil {
+			u.hostPortDB = []string{"", "", u.Path}
+		}
+		z := &url.URL{
+			Scheme:   "file",
+			Path:     u.Path,
+			RawQuery: u.RawQuery,
+		}
+		return z.String(), "", nil
+	}
+	dsn, _, err := GenMysql(u)
+	if err != nil {
+		return "", "", err
+	}
+	return dsn, "mysql", nil
 }
 
 // GenExasol generates a exasol DSN from the passed URL.
 func GenExasol(u *URL) (string, string, error) {
 	host, port, dbname := u.Hostname(), u.Port(), strings.TrimPrefix(u.Path, "/")
-	if host == "" {
-		host = "localhost"
-	}
-	if port == "" {
-		port = "8563"
+	if u.defaults() {
+		host, port = defaultString(host, "localhost"), defaultString(port, "8563")
 	}
 	q := u.Query()
 	if dbname != "" {
 		q.Set("schema", dbname)
 	}
+	var empty []string
 	if u.User != nil {
 		q.Set("user", u.User.Username())
-		pass, _ := u.User.Password()
-		q.Set("password", pass)
+		empty = setPassword(q, "password", u.User)
 	}
-	return fmt.Sprintf("exa:%s:%s%s", host, port, genOptions(q, ";", "=", ";", ",", true, nil, nil)), "", nil
+	return fmt.Sprintf("exa:%s%s", joinHostPort(host, port), genOptions(q, ";", "=", ";", ",", escapeNone, true, nil, nil, empty...)), "", nil
 }
 
 // GenFirebird generates a firebird DSN from the passed URL.
@@ -287,10 +500,7 @@ func GenGodror(u *URL) (string, string, error) {
 		instance, service = service[i+1:], service[:i]
 	}
 	// build dsn
-	dsn := host
-	if port != "" {
-		dsn += ":" + port
-	}
+	dsn := joinHostPort(host, port)
 	if u.User != nil {
 		if n := u.User.Username(); n != "" {
 			if p, ok := u.User.Password(); ok {
@@ -308,20 +518,39 @@ func GenGodror(u *URL) (string, string, error) {
 	return dsn, "", nil
 }
 
+// GenHeavyDB generates a heavydb DSN from the passed URL, using the Thrift
+// binary protocol, or the http(s) endpoint when the "http" or "https"
+// transport is specified.
+func GenHeavyDB(u *URL) (string, string, error) {
+	switch strings.ToLower(u.Transport) {
+	case "", "tcp":
+		return heavydbBinary(u)
+	case "http":
+		return heavydbHTTP(u)
+	case "https":
+		return heavydbHTTPS(u)
+	}
+	return "", "", ErrInvalidTransportProtocol
+}
+
+// heavydb generators.
+var (
+	heavydbBinary = GenFromURL("truncate://localhost:6274/")
+	heavydbHTTP   = GenFromURL("http://localhost:6278/")
+	heavydbHTTPS  = GenFromURL("https://localhost:6278/")
+)
+
 // GenIgnite generates an ignite DSN from the passed URL.
 func GenIgnite(u *URL) (string, string, error) {
-	host, port, dbname := "localhost", "10800", strings.TrimPrefix(u.Path, "/")
-	if h := u.Hostname(); h != "" {
-		host = h
-	}
-	if p := u.Port(); p != "" {
-		port = p
+	host, port, dbname := u.Hostname(), u.Port(), strings.TrimPrefix(u.Path, "/")
+	if u.defaults() {
+		host, port = defaultString(host, "localhost"), defaultString(port, "10800")
 	}
 	q := u.Query()
 	// add user/pass
 	if u.User != nil {
 		q.Set("username", u.User.Username())
-		if pass, _ := u.User.Password(); pass != "" {
+		if pass, ok := u.User.Password(); ok {
 			q.Set("password", pass)
 		}
 	}
@@ -329,18 +558,119 @@ func GenIgnite(u *URL) (string, string, error) {
 	if dbname != "" {
 		dbname = "/" + dbname
 	}
-	return "tcp://" + host + ":" + port + dbname + genQueryOptions(q), "", nil
+	return "tcp://" + joinHostPort(host, port) + dbname + genQueryOptions(q), "", nil
+}
+
+// GenMaxCompute generates a maxcompute (ODPS) DSN from the passed URL.
+//
+// The host may be a region (ie, "mc://id:key@cn-hangzhou/myproject"), which
+// is expanded to the region's service endpoint, and the path is used as the
+// project when "curr_project" is not specified. The "https" transport uses an
+// https endpoint.
+func GenMaxCompute(u *URL) (string, string, error) {
+	if u.User == nil {
+		return "", "", ErrMissingUser
+	}
+	host, port := u.Hostname(), u.Port()
+	switch {
+	case host == "":
+		host = "service.maxcompute.aliyun.com"
+	case !strings.Contains(host, "."):
+		host = "service." + host + ".maxcompute.aliyun.com"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	scheme := "http"
+	switch strings.ToLower(u.Transport) {
+	case "tcp", "http":
+	case "https":
+		scheme = "https"
+	default:
+		return "", "", ErrInvalidTransportProtocol
+	}
+	q := u.Query()
+	if project := strings.TrimPrefix(u.Path, "/"); project != "" && project != "api" && q.Get("curr_project") == "" {
+		q.Set("curr_project", project)
+	}
+	z := &url.URL{
+		Scheme:   scheme,
+		User:     u.User,
+		Host:     host,
+		Path:     "/api",
+		RawQuery: q.Encode(),
+	}
+	return z.String(), "", nil
 }
 
+// GenKinetica generates a kinetica DSN from the passed URL.
+//
+// The path is used as the schema, and query parameters are passed as
+// ";key=value" options, with repeated values (ie, multi-head ingest worker
+// URLs) joined by commas.
+func GenKinetica(u *URL) (string, string, error) {
+	host, port := u.Hostname(), u.Port()
+	if u.defaults() {
+		host, port = defaultString(host, "localhost"), defaultString(port, "9191")
+	}
+	scheme := "http"
+	switch strings.ToLower(u.Transport) {
+	case "tcp", "http":
+	case "https":
+		scheme = "https"
+	default:
+		return "", "", ErrInvalidTransportProtocol
+	}
+	z := &url.URL{
+		Scheme: scheme,
+		User:   u.User,
+		Host:   joinHostPort(host, port),
+	}
+	q := u.Query()
+	if schema := strings.TrimPrefix(u.Path, "/"); schema != "" {
+		q.Set("schema", schema)
+	}
+	return z.String() + genOptionsOrder(q, []string{"schema"}, ";", "=", ";", ",", escapeNone, true, nil, nil), "", nil
+}
+
+// GenMaterialize generates a materialize DSN from the passed URL.
+//
+// The "cluster" query parameter is passed to materialize as the "--cluster"
+// connection option (ie, "mz://localhost/materialize?cluster=quickstart").
+func GenMaterialize(u *URL) (string, string, error) {
+	q := u.Query()
+	if cluster := q.Get("cluster"); cluster != "" {
+		opts := "--cluster=" + cluster
+		if s := q.Get("options"); s != "" {
+			opts = s + " " + opts
+		}
+		q.Del("cluster")
+		q.Set("options", opts)
+	}
+	z := *u
+	z.RawQuery = q.Encode()
+	return materialize(&z)
+}
+
+// materialize generator.
+var materialize = GenFromURL("postgres://localhost:6875/")
+
 // GenMymysql generates a mymysql DSN from the passed URL.
 func GenMymysql(u *URL) (string, string, error) {
 	host, port, dbname := u.Hostname(), u.Port(), strings.TrimPrefix(u.Path, "/")
+	transport, q := u.Transport, u.Query()
 	// resolve path
-	if u.Transport == "unix" {
+	switch socket := q.Get("socket"); {
+	case host == "" && socket != "":
+		// socket specified via query (ie,
+		// "my://user:pass@/dbname?socket=/var/run/mysqld/mysqld.sock")
+		host, port, transport = socket, "", "unix"
+		q.Del("socket")
+	case transport == "unix":
 		if host == "" {
 			dbname = "/" + dbname
 		}
-		host, dbname = resolveSocket(path.Join(host, dbname))
+		host, dbname = resolveSocket(path.Join(host, dbname), u.opts)
 		port = ""
 	}
 	// save host, port, dbname
@@ -348,22 +678,17 @@ func GenMymysql(u *URL) (string, string, error) {
 		u.hostPortDB = []string{host, port, dbname}
 	}
 	// if host or proto is not empty
-	if u.Transport != "unix" {
-		if host == "" {
-			host = "localhost"
-		}
-		if port == "" {
-			port = "3306"
+	if transport != "unix" {
+		if u.defaults() {
+			host, port = defaultString(host, "localhost"), defaultString(port, "3306")
 		}
-	}
-	if port != "" {
-		port = ":" + port
+		host = joinHostPort(host, port)
 	}
 	// build dsn
-	dsn := u.Transport + ":" + host + port
+	dsn := transport + ":" + host
 	dsn += genOptions(
-		convertOptions(u.Query(), "true", ""),
-		",", "=", ",", " ", false,
+		convertOptions(q, "true", ""),
+		",", "=", ",", " ", escapeNone, false,
 		nil, nil,
 	)
 	dsn += "*" + dbname
@@ -389,12 +714,19 @@ func GenMysql(u *URL) (string, string, error) {
 			dsn += n + "@"
 		}
 	}
+	transport, q := u.Transport, u.Query()
 	// resolve path
-	if u.Transport == "unix" {
+	switch socket := q.Get("socket"); {
+	case host == "" && socket != "":
+		// socket specified via query (ie,
+		// "my://user:pass@/dbname?socket=/var/run/mysqld/mysqld.sock")
+		host, port, transport = socket, "", "unix"
+		q.Del("socket")
+	case transport == "unix":
 		if host == "" {
 			dbname = "/" + dbname
 		}
-		host, dbname = resolveSocket(path.Join(host, dbname))
+		host, dbname = resolveSocket(path.Join(host, dbname), u.opts)
 		port = ""
 	}
 	// save host, port, dbname
@@ -402,20 +734,29 @@ func GenMysql(u *URL) (string, string, error) {
 		u.hostPortDB = []string{host, port, dbname}
 	}
 	// if host or proto is not empty
-	if u.Transport != "unix" {
+	switch {
+	case transport == "unix":
+	case !u.defaults() && host == "" && port == "":
+		// let the driver use its default address
+		return dsn + "/" + dbname + genQueryOptions(q), "", nil
+	case u.defaults():
 		if host == "" {
 			host = "localhost"
 		}
 		if port == "" {
 			port = "3306"
+			// wire compatible schemes (ie, tidb, doris)
+			if scheme, ok := lookupScheme(u.UnaliasedDriver); ok && scheme.DefaultPort != "" {
+				port = scheme.DefaultPort
+			}
 		}
 	}
-	if port != "" {
-		port = ":" + port
+	if transport != "unix" {
+		host = joinHostPort(host, port)
 	}
 	// add proto and database
-	dsn += u.Transport + "(" + host + port + ")" + "/" + dbname
-	return dsn + genQueryOptions(u.Query()), "", nil
+	dsn += transport + "(" + host + ")" + "/" + dbname
+	return dsn + genQueryOptions(q), "", nil
 }
 
 // GenOdbc generates a odbc DSN from the passed URL.
@@ -427,9 +768,12 @@ func GenOdbc(u *URL) (string, string, error) {
 	}
 	// build q
 	q := u.Query()
+	foldKeys(q, odbcKeys)
 	q.Set("Driver", "{"+strings.Replace(u.Transport, "+", " ", -1)+"}")
 	q.Set("Server", host)
-	if port == "" {
+	switch {
+	case port == "" && !u.defaults():
+	case port == "":
 		proto := strings.ToLower(u.Transport)
 		switch {
 		case strings.Contains(proto, "mysql"):
@@ -441,26 +785,28 @@ func GenOdbc(u *URL) (string, string, error) {
 		default:
 			q.Set("Port", "1433")
 		}
-	} else {
+	default:
 		q.Set("Port", port)
 	}
 	q.Set("Database", dbname)
 	// add user/pass
+	var empty []string
 	if u.User != nil {
 		q.Set("UID", u.User.Username())
-		p, _ := u.User.Password()
-		q.Set("PWD", p)
+		empty = setPassword(q, "PWD", u.User)
 	}
-	return genOptionsOdbc(q, true, nil, OdbcIgnoreQueryPrefixes), "", nil
+	order := u.queryOrder("Driver", "Server", "Port", "ServiceName", "Database", "UID", "PWD")
+	return genOptionsOdbc(q, true, nil, u.ignoreQueryPrefixes(), order, empty...), "", nil
 }
 
 // GenOleodbc generates a oleodbc DSN from the passed URL.
 func GenOleodbc(u *URL) (string, string, error) {
 	props, _, err := GenOdbc(u)
 	if err != nil {
-		return "", "", nil
+		return "", "", err
 	}
-	return `Provider=MSDASQL.1;Extended Properties="` + props + `"`, "", nil
+	// double quotes are escaped by doubling within a quoted value
+	return `Provider=MSDASQL.1;Extended Properties="` + strings.ReplaceAll(props, `"`, `""`) + `"`, "", nil
 }
 
 // GenPostgres generates a postgres DSN from the passed URL.
@@ -469,32 +815,53 @@ func GenPostgres(u *URL) (string, string, error) {
 	if host == "." {
 		return "", "", ErrRelativePathNotSupported
 	}
+	q := u.Query()
 	// resolve path
-	if u.Transport == "unix" {
+	switch qhost := q.Get("host"); {
+	case strings.Contains(u.Host, ","):
+		// multiple hosts (ie, "host1:5432,host2:5433"), as comma separated
+		// hosts and ports
+		hosts, ports := splitHosts(u.Host)
+		defaultPort := "5432"
+		if scheme, ok := lookupScheme(u.UnaliasedDriver); ok && scheme.DefaultPort != "" {
+			defaultPort = scheme.DefaultPort
+		}
+		host, port = joinPorts(hosts, ports, defaultPort)
+	case host == "" && (strings.HasPrefix(qhost, "/") || strings.HasPrefix(qhost, "@")):
+		// socket directory and port specified via query (ie,
+		// "pg:///dbname?host=/var/run/postgresql&port=6666")
+		host = qhost
+		if port == "" {
+			port = q.Get("port")
+		}
+	case u.Transport == "unix":
 		if host == "" {
 			dbname = "/" + dbname
 		}
-		host, port, dbname = resolveDir(path.Join(host, dbname))
+		host, port, dbname = resolveDir(path.Join(host, dbname), u.opts)
 	}
 	// build q
-	q := u.Query()
 	q.Set("host", host)
 	q.Set("port", port)
 	q.Set("dbname", dbname)
 	// add user/pass
+	var empty []string
 	if u.User != nil {
 		q.Set("user", u.User.Username())
-		pass, _ := u.User.Password()
-		q.Set("password", pass)
+		empty = setPassword(q, "password", u.User)
 	}
 	// save host, port, dbname
 	if u.hostPortDB == nil {
 		u.hostPortDB = []string{host, port, dbname}
 	}
-	return genOptions(q, "", "=", " ", ",", true, nil, nil), "", nil
+	return genOptions(q, "", "=", " ", ",", escapeKeyword, true, nil, nil, empty...), "", nil
 }
 
 // GenPresto generates a presto DSN from the passed URL.
+//
+// The catalog and schema are taken from the path (ie,
+// "trino://host/catalog/schema"), or from the "catalog" and "schema" query
+// parameters, which take precedence.
 func GenPresto(u *URL) (string, string, error) {
 	z := &url.URL{
 		Scheme:   "http",
@@ -508,16 +875,12 @@ func GenPresto(u *URL) (string, string, error) {
 	if strings.HasSuffix(u.OriginalScheme, "s") {
 		z.Scheme = "https"
 	}
-	// force user
-	if z.User == nil {
-		z.User = url.User("user")
-	}
 	// force host
-	if z.Host == "" {
+	if z.Host == "" && u.defaults() {
 		z.Host = "localhost"
 	}
 	// force port
-	if z.Port() == "" {
+	if z.Port() == "" && z.Host != "" && u.defaults() {
 		if z.Scheme == "http" {
 			z.Host += ":8080"
 		} else if z.Scheme == "https" {
@@ -527,11 +890,12 @@ func GenPresto(u *URL) (string, string, error) {
 	// add parameters
 	q := z.Query()
 	dbname, schema := strings.TrimPrefix(u.Path, "/"), ""
-	if dbname == "" {
-		dbname = "default"
-	} else if i := strings.Index(dbname, "/"); i != -1 {
+	if i := strings.Index(dbname, "/"); i != -1 {
 		schema, dbname = dbname[i+1:], dbname[:i]
 	}
+	// catalog and schema query parameters take precedence
+	dbname = defaultString(q.Get("catalog"), dbname, "default")
+	schema = defaultString(q.Get("schema"), schema)
 	q.Set("catalog", dbname)
 	if schema != "" {
 		q.Set("schema", schema)
@@ -540,24 +904,54 @@ func GenPresto(u *URL) (string, string, error) {
 	return z.String(), "", nil
 }
 
+// GenSqld generates a sqld (libSQL server) DSN from the passed URL, using
+// the transport as the scheme ("http" by default), and passing the
+// password (or user name when no password is specified) as the "authToken"
+// query parameter.
+func GenSqld(u *URL) (string, string, error) {
+	host := u.Host
+	if host == "" {
+		return "", "", ErrMissingHost
+	}
+	scheme := strings.ToLower(u.Transport)
+	switch scheme {
+	case "tcp":
+		scheme = "http"
+	case "http", "https", "ws", "wss", "libsql":
+	default:
+		return "", "", ErrInvalidTransportProtocol
+	}
+	if scheme == "http" && u.Port() == "" && u.defaults() {
+		host += ":8080"
+	}
+	q := u.Query()
+	if token := userToken(u); token != "" {
+		q.Set("authToken", token)
+	}
+	z := &url.URL{
+		Scheme:   scheme,
+		Host:     host,
+		Path:     u.Path,
+		RawQuery: q.Encode(),
+	}
+	return z.String(), "", nil
+}
+
 // GenSnowflake generates a snowflake DSN from the passed URL.
 func GenSnowflake(u *URL) (string, string, error) {
 	host, port, dbname := u.Hostname(), u.Port(), strings.TrimPrefix(u.Path, "/")
 	if host == "" {
 		return "", "", ErrMissingHost
 	}
-	if port != "" {
-		port = ":" + port
-	}
 	// add user/pass
 	if u.User == nil {
 		return "", "", ErrMissingUser
 	}
 	user := u.User.Username()
-	if pass, _ := u.User.Password(); pass != "" {
+	if pass, ok := u.User.Password(); ok {
 		user += ":" + pass
 	}
-	return user + "@" + host + port + "/" + dbname + genQueryOptions(u.Query()), "", nil
+	return user + "@" + joinHostPort(host, port) + "/" + dbname + genQueryOptions(u.Query()), "", nil
 }
 
 // GenSpanner generates a spanner DSN from the passed URL.
@@ -577,6 +971,55 @@ func GenSpanner(u *URL) (string, string, error) {
 	return fmt.Sprintf(`projects/%s/instances/%s/databases/%s`, project, instance, dbname), "", nil
 }
 
+// GenSparkSQL generates a Spark Thrift Server (or Kyuubi) DSN for the hive
+// driver from the passed URL.
+//
+// The "transport" query parameter may be "binary" (the default) or "http".
+// When "http", the "httpPath" query parameter defaults to "cliservice".
+func GenSparkSQL(u *URL) (string, string, error) {
+	q := u.Query()
+	switch transport := q.Get("transport"); transport {
+	case "", "binary":
+	case "http":
+		if q.Get("httpPath") == "" {
+			q.Set("httpPath", "cliservice")
+		}
+	default:
+		return "", "", &ParamError{u.UnaliasedDriver, "transport", transport, ErrInvalidParameter}
+	}
+	z := *u
+	z.RawQuery = q.Encode()
+	return sparksql(&z)
+}
+
+// sparksql generator.
+var sparksql = GenFromURL("truncate://localhost:10009/")
+
+// GenSqlite3 generates a sqlite3 DSN from the passed URL. When the "shared"
+// query parameter is true, generates a shared cache in-memory database URI
+// DSN (ie, "file:memdb1?mode=memory&cache=shared" for "sq://memdb1?shared=true"),
+// allowing multiple connections in the same process to share the database.
+func GenSqlite3(u *URL) (string, string, error) {
+	q := u.Query()
+	if !q.Has("shared") {
+		return GenOpaque(u)
+	}
+	shared, err := strconv.ParseBool(q.Get("shared"))
+	switch {
+	case err != nil:
+		return "", "", ErrInvalidQuery
+	case u.Opaque == "":
+		return "", "", ErrMissingPath
+	case !shared:
+		return genOpaque(u, stripRawQuery(u.RawQuery, "shared")), "", nil
+	}
+	dsn := "file:" + strings.TrimPrefix(u.Opaque, "file:") + "?mode=memory&cache=shared"
+	if s := stripRawQuery(u.RawQuery, "shared", "mode", "cache"); s != "" {
+		dsn += "&" + s
+	}
+	return dsn, "", nil
+}
+
 // GenSqlserver generates a sqlserver DSN from the passed URL.
 func GenSqlserver(u *URL) (string, string, error) {
 	z := &url.URL{
@@ -588,7 +1031,7 @@ func GenSqlserver(u *URL) (string, string, error) {
 		RawQuery: u.RawQuery,
 		Fragment: u.Fragment,
 	}
-	if z.Host == "" {
+	if z.Host == "" && u.defaults() {
 		z.Host = "localhost"
 	}
 	driver := "sqlserver"
@@ -632,34 +1075,111 @@ func GenTableStore(u *URL) (string, string, error) {
 
 // GenVoltdb generates a voltdb DSN from the passed URL.
 func GenVoltdb(u *URL) (string, string, error) {
-	host, port := "localhost", "21212"
-	if h := u.Hostname(); h != "" {
-		host = h
-	}
-	if p := u.Port(); p != "" {
-		port = p
+	host, port := u.Hostname(), u.Port()
+	if u.defaults() {
+		host, port = defaultString(host, "localhost"), defaultString(port, "21212")
 	}
-	return host + ":" + port, "", nil
+	return joinHostPort(host, port), "", nil
 }
 
 // GenYDB generates a ydb dsn from the passed URL.
 func GenYDB(u *URL) (string, string, error) {
-	scheme, host, port := "grpc", "localhost", "2136"
+	scheme, defaultPort := "grpc", "2136"
 	if strings.HasSuffix(strings.ToLower(u.OriginalScheme), "s") {
-		scheme, port = "grpcs", "2135"
-	}
-	if h := u.Hostname(); h != "" {
-		host = h
+		scheme, defaultPort = "grpcs", "2135"
 	}
-	if p := u.Port(); p != "" {
-		port = p
+	host, port := u.Hostname(), u.Port()
+	if u.defaults() {
+		host, port = defaultString(host, "localhost"), defaultString(port, defaultPort)
 	}
 	var userpass string
 	if u.User != nil {
 		userpass = u.User.String() + "@"
 	}
-	s := scheme + "://" + userpass + host + ":" + port + "/" + strings.TrimPrefix(u.Path, "/")
-	return s + genOptions(u.Query(), "?", "=", "&", ",", true, nil, nil), "", nil
+	s := scheme + "://" + userpass + joinHostPort(host, port) + "/" + strings.TrimPrefix(u.Path, "/")
+	return s + genOptions(u.Query(), "?", "=", "&", ",", escapePercent, true, nil, nil), "", nil
+}
+
+// joinHostPort joins the host and port, bracketing IPv6 hosts.
+func joinHostPort(host, port string) string {
+	switch {
+	case port != "":
+		return net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// splitHosts splits a comma separated multiple host URL host (ie,
+// "host1:5432,host2") into its hosts and ports.
+func splitHosts(hostport string) ([]string, []string) {
+	var hosts, ports []string
+	for _, s := range strings.Split(hostport, ",") {
+		host, port, err := net.SplitHostPort(s)
+		if err != nil {
+			host, port = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"), ""
+		}
+		hosts, ports = append(hosts, host), append(ports, port)
+	}
+	return hosts, ports
+}
+
+// cutHosts cuts the comma separated multiple host list (ie,
+// "host1:5432,host2") from the authority of a URL string, returning the URL
+// string without the hosts, and the hosts. Each host is validated separately,
+// as [url.Parse] only accepts a single port, and so rejects lists where only
+// some hosts have a port (ie, "host1:5432,host2"). Returns the URL string
+// unchanged when the URL does not have multiple hosts.
+func cutHosts(urlstr string) (string, string, error) {
+	i := strings.Index(urlstr, "://")
+	if i == -1 {
+		return urlstr, "", nil
+	}
+	start, end := i+3, len(urlstr)
+	if j := strings.IndexAny(urlstr[start:], "/?#"); j != -1 {
+		end = start + j
+	}
+	if j := strings.LastIndex(urlstr[start:end], "@"); j != -1 {
+		start += j + 1
+	}
+	hosts := urlstr[start:end]
+	if !strings.Contains(hosts, ",") {
+		return urlstr, "", nil
+	}
+	for _, s := range strings.Split(hosts, ",") {
+		if s == "" {
+			return "", "", ErrMissingHost
+		}
+		if _, err := url.Parse("//" + s); err != nil {
+			return "", "", err
+		}
+	}
+	return urlstr[:start] + urlstr[end:], hosts, nil
+}
+
+// joinHosts joins the hosts and ports as a comma separated multiple host URL
+// host.
+func joinHosts(hosts, ports []string) string {
+	v := make([]string, len(hosts))
+	for i, host := range hosts {
+		v[i] = joinHostPort(host, ports[i])
+	}
+	return strings.Join(v, ",")
+}
+
+// joinPorts joins the hosts and ports as comma separated hosts and ports, as
+// used by libpq style DSNs, using the default port for hosts without a port.
+// When no host has a port, the returned ports are empty.
+func joinPorts(hosts, ports []string, defaultPort string) (string, string) {
+	if !slices.ContainsFunc(ports, func(s string) bool { return s != "" }) {
+		return strings.Join(hosts, ","), ""
+	}
+	v := make([]string, len(ports))
+	for i, port := range ports {
+		v[i] = defaultString(port, defaultPort)
+	}
+	return strings.Join(hosts, ","), strings.Join(v, ",")
 }
 
 // convertOptions converts an option value based on name, value pairs.
@@ -680,6 +1200,20 @@ func convertOptions(q url.Values, pairs ...string) url.Values {
 	return n
 }
 
+// mergeQuery merges src into dst key-wise using the merge policy, preserving
+// all values of repeated keys.
+func mergeQuery(dst, src url.Values, merge QueryMerge) url.Values {
+	for k, v := range src {
+		switch _, ok := dst[k]; {
+		case !ok, merge == QueryMergeReplace:
+			dst[k] = append([]string(nil), v...)
+		case merge == QueryMergeAppend:
+			dst[k] = append(dst[k], v...)
+		}
+	}
+	return dst
+}
+
 // genQueryOptions generates standard query options.
 func genQueryOptions(q url.Values) string {
 	if s := q.Encode(); s != "" {
@@ -688,53 +1222,143 @@ func genQueryOptions(q url.Values) string {
 	return ""
 }
 
-// genOptionsOdbc is a util wrapper around genOptions that uses the fixed
+// genOptionsOdbc is a util wrapper around genOptionsOrder that uses the fixed
 // settings for ODBC style connection strings.
-func genOptionsOdbc(q url.Values, skipWhenEmpty bool, ignore, ignorePrefixes []string) string {
-	return genOptions(q, "", "=", ";", ",", skipWhenEmpty, ignore, ignorePrefixes)
+func genOptionsOdbc(q url.Values, skipWhenEmpty bool, ignore, ignorePrefixes, order []string, empty ...string) string {
+	return genOptionsOrder(q, order, "", "=", ";", ",", escapeBrace, skipWhenEmpty, ignore, ignorePrefixes, empty...)
+}
+
+// Canonical keys of the case-insensitive ODBC style connection strings. See
+// foldKeys.
+var (
+	adodbKeys  = []string{"Provider", "Port", "Data Source", "Database", "User ID", "Password", "Initial Catalog", "Extended Properties", "Integrated Security", "Persist Security Info"}
+	cosmosKeys = []string{"AccountEndpoint", "AccountKey", "Db"}
+	odbcKeys   = []string{"Driver", "DSN", "Server", "Port", "ServiceName", "Database", "UID", "PWD", "Trusted_Connection", "Encrypt", "TrustServerCertificate"}
+)
+
+// foldKeys folds the keys of q for case-insensitive connection string
+// formats, renaming keys to the canonical casing (ie, "UID" for "uid") and
+// merging keys that differ only by case, so that query parameters are merged
+// with the keys set by the generator. When a key is specified more than once
+// with differing case, the values of the canonical (or first sorted) key are
+// used.
+func foldKeys(q url.Values, canonical []string) {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	names := make(map[string]string, len(canonical)+len(keys))
+	for _, k := range canonical {
+		names[strings.ToLower(k)] = k
+	}
+	for _, k := range keys {
+		name, ok := names[strings.ToLower(k)]
+		switch {
+		case !ok:
+			names[strings.ToLower(k)] = k
+			continue
+		case name == k:
+			continue
+		}
+		if _, ok := q[name]; !ok {
+			q[name] = q[k]
+		}
+		delete(q, k)
+	}
 }
 
 // genOptions takes URL values and generates options, joining together with
-// joiner, and separated by sep, with any multi URL values joined by valSep,
-// ignoring any values with keys in ignore.
+// joiner, and separated by sep, with any multi URL values joined by valSep and
+// escaped using the escaping policy, ignoring any values with keys in ignore.
+// Keys in empty are explicitly empty values (see setPassword), and are
+// generated even when skipWhenEmpty is true.
 //
 // For example, to build a "ODBC" style connection string, can be used like the
 // following:
 //
-//	genOptions(u.Query(), "", "=", ";", ",", false)
-func genOptions(q url.Values, joiner, assign, sep, valSep string, skipWhenEmpty bool, ignore, ignorePrefixes []string) string {
+//	genOptions(u.Query(), "", "=", ";", ",", escapeBrace, false)
+func genOptions(q url.Values, joiner, assign, sep, valSep string, esc escaping, skipWhenEmpty bool, ignore, ignorePrefixes []string, empty ...string) string {
+	return genOptionsOrder(q, nil, joiner, assign, sep, valSep, esc, skipWhenEmpty, ignore, ignorePrefixes, empty...)
+}
+
+// genOptionsOrder is the same as genOptions, but generates the keys listed in
+// order first (in the listed order), followed by any remaining keys sorted.
+func genOptionsOrder(q url.Values, order []string, joiner, assign, sep, valSep string, esc escaping, skipWhenEmpty bool, ignore, ignorePrefixes []string, empty ...string) string {
 	if len(q) == 0 {
 		return ""
 	}
-	// make ignore map
-	ig := make(map[string]bool, len(ignore))
-	for _, v := range ignore {
-		ig[strings.ToLower(v)] = true
+	// order keys
+	p := keysPool.Get().(*[]string)
+	s := (*p)[:0]
+	defer func() {
+		clear(s)
+		*p = s[:0]
+		keysPool.Put(p)
+	}()
+	for _, k := range order {
+		if _, ok := q[k]; ok && !contains(s, k) {
+			s = append(s, k)
+		}
 	}
-	// sort keys
-	s := make([]string, len(q))
-	var i int
-	for k := range q {
-		s[i] = k
-		i++
+	n, size := len(s), len(joiner)
+	for k, v := range q {
+		if !contains(s[:n], k) {
+			s = append(s, k)
+		}
+		size += len(k) + len(assign) + len(sep)
+		for _, z := range v {
+			size += len(z) + len(valSep)
+		}
 	}
-	sort.Strings(s)
-	var opts []string
+	slices.Sort(s[n:])
+	// build
+	var b strings.Builder
+	b.Grow(size)
+	first := true
 	for _, k := range s {
-		if s := strings.ToLower(k); !ig[s] && !hasPrefix(s, ignorePrefixes) {
-			val := strings.Join(q[k], valSep)
-			if !skipWhenEmpty || val != "" {
-				if val != "" {
-					val = assign + val
-				}
-				opts = append(opts, k+val)
-			}
+		if containsFold(ignore, k) || hasPrefix(strings.ToLower(k), ignorePrefixes) {
+			continue
+		}
+		val := esc.join(q[k], valSep)
+		isEmpty := val == "" && contains(empty, k)
+		if isEmpty && esc == escapeKeyword {
+			val = "''"
+		}
+		if skipWhenEmpty && val == "" && !isEmpty {
+			continue
+		}
+		if first {
+			b.WriteString(joiner)
+			first = false
+		} else {
+			b.WriteString(sep)
+		}
+		b.WriteString(esc.key(k))
+		if val != "" || isEmpty {
+			b.WriteString(assign)
+			b.WriteString(val)
 		}
 	}
-	if len(opts) != 0 {
-		return joiner + strings.Join(opts, sep)
+	return b.String()
+}
+
+// keysPool is a pool of key buffers used by genOptionsOrder.
+var keysPool = sync.Pool{
+	New: func() interface{} {
+		s := make([]string, 0, 16)
+		return &s
+	},
+}
+
+// containsFold returns true when v contains s, ignoring case.
+func containsFold(v []string, s string) bool {
+	for _, z := range v {
+		if strings.EqualFold(z, s) {
+			return true
+		}
 	}
-	return ""
+	return false
 }
 
 // hasPrefix returns true when s begins with any listed prefix.
@@ -0,0 +1,102 @@
+package dburl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// envNames are the native client environment variable names for the host,
+// port, user, password, and database, by database family.
+var envNames = map[string][5]string{
+	"mysql":     {"MYSQL_HOST", "MYSQL_TCP_PORT", "MYSQL_USER", "MYSQL_PWD", "MYSQL_DATABASE"},
+	"postgres":  {"PGHOST", "PGPORT", "PGUSER", "PGPASSWORD", "PGDATABASE"},
+	"sqlserver": {"SQLCMDSERVER", "", "SQLCMDUSER", "SQLCMDPASSWORD", "SQLCMDDBNAME"},
+}
+
+// envFamilies are the postgres wire compatible drivers without an Override,
+// whose native clients (psql) use the postgres environment variables.
+var envFamilies = map[string]string{
+	"cockroachdb": "postgres",
+	"greenplum":   "postgres",
+	"redshift":    "postgres",
+	"risingwave":  "postgres",
+	"timescale":   "postgres",
+}
+
+// Env returns environment variables for the URL's host, port, user,
+// password, and database, for use when executing the database's native
+// command-line client (ie, psql, mysql, sqlcmd) with equivalent settings.
+//
+// When prefix is empty, the native client variable names of the URL's
+// database family are used:
+//
+//	postgres   PGHOST, PGPORT, PGUSER, PGPASSWORD, PGDATABASE, PGSSLMODE
+//	mysql      MYSQL_HOST (or MYSQL_UNIX_PORT), MYSQL_TCP_PORT, MYSQL_USER,
+//	           MYSQL_PWD, MYSQL_DATABASE
+//	sqlserver  SQLCMDSERVER, SQLCMDUSER, SQLCMDPASSWORD, SQLCMDDBNAME
+//
+// and nil is returned for other databases. Otherwise, the variables are
+// named with the prefix (ie, "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD",
+// "DB_DATABASE" for "DB_"). Empty values are not included.
+func (u *URL) Env(prefix string) map[string]string {
+	host, port, dbname := u.Hostname(), u.Port(), strings.TrimPrefix(u.Path, "/")
+	if u.hostPortDB != nil {
+		host, port, dbname = u.hostPortDB[0], u.hostPortDB[1], u.hostPortDB[2]
+	}
+	if port == "" {
+		if v, err := url.Parse(u.DSN); err == nil && v.Port() != "" {
+			port = v.Port()
+		}
+	}
+	var user, pass string
+	if u.User != nil {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+	}
+	names := [5]string{prefix + "HOST", prefix + "PORT", prefix + "USER", prefix + "PASSWORD", prefix + "DATABASE"}
+	m := make(map[string]string)
+	if prefix == "" {
+		f := u.envFamily()
+		var ok bool
+		if names, ok = envNames[f]; !ok {
+			return nil
+		}
+		switch f {
+		case "postgres":
+			if s := u.Query().Get("sslmode"); s != "" {
+				m["PGSSLMODE"] = s
+			}
+		case "mysql":
+			if u.Transport == "unix" {
+				names[0] = "MYSQL_UNIX_PORT"
+			}
+		case "sqlserver":
+			// sqlcmd's server is "host[\instance][,port]"
+			if v := strings.Split(strings.TrimPrefix(u.Path, "/"), "/"); len(v) > 1 {
+				host, dbname = host+`\`+v[0], v[len(v)-1]
+			}
+			if s := u.Query().Get("database"); s != "" {
+				dbname = s
+			}
+			if port != "" {
+				host += "," + port
+			}
+		}
+	}
+	for i, s := range []string{host, port, user, pass, dbname} {
+		if names[i] != "" && s != "" {
+			m[names[i]] = s
+		}
+	}
+	return m
+}
+
+// envFamily returns the database family used for the URL's native client
+// environment variables.
+func (u *URL) envFamily() string {
+	scheme, ok := lookupScheme(u.UnaliasedDriver)
+	if !ok {
+		return ""
+	}
+	return wireFamily(scheme)
+}
@@ -0,0 +1,120 @@
+package dburl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// escaping is a DSN value escaping policy.
+type escaping int
+
+// Escaping policies.
+const (
+	// escapeNone does not escape values.
+	escapeNone escaping = iota
+	// escapeKeyword single quotes values containing whitespace, quotes, or
+	// backslashes, as used by libpq style "key=value" DSNs.
+	escapeKeyword
+	// escapeBrace brace quotes values containing semicolons, braces, or
+	// surrounding whitespace, as used by ODBC style "key=value;" DSNs.
+	escapeBrace
+	// escapePercent percent encodes values, as used by URL query style
+	// "?key=value&" DSNs.
+	escapePercent
+)
+
+// setPassword sets key to the user's password, when the password is set,
+// returning the keys of any explicitly empty password (ie, "user:@host") for
+// use as the empty keys of genOptions.
+func setPassword(q url.Values, key string, user *url.Userinfo) []string {
+	pass, ok := user.Password()
+	if !ok {
+		return nil
+	}
+	q.Set(key, pass)
+	if pass == "" {
+		return []string{key}
+	}
+	return nil
+}
+
+// join escapes and joins the values with sep.
+func (e escaping) join(v []string, sep string) string {
+	switch e {
+	case escapeKeyword:
+		return quoteKeyword(strings.Join(v, sep))
+	case escapeBrace:
+		return quoteBrace(strings.Join(v, sep))
+	case escapePercent:
+		if len(v) == 1 {
+			return url.QueryEscape(v[0])
+		}
+		z := make([]string, len(v))
+		for i, s := range v {
+			z[i] = url.QueryEscape(s)
+		}
+		return strings.Join(z, sep)
+	}
+	return strings.Join(v, sep)
+}
+
+// key escapes the key.
+func (e escaping) key(k string) string {
+	if e == escapePercent {
+		return url.QueryEscape(k)
+	}
+	return k
+}
+
+// quoteKeyword single quotes s when it contains whitespace, single quotes, or
+// backslashes, escaping single quotes and backslashes.
+func quoteKeyword(s string) string {
+	if !strings.ContainsAny(s, " \t\r\n\f\v'\\") {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// quoteBrace brace quotes s when it contains semicolons, braces, or
+// surrounding whitespace, doubling closing braces. Values already enclosed in
+// braces (ie, "{ODBC Driver 18 for SQL Server}") or double quotes (as
+// accepted by OLE DB) are not quoted again.
+func quoteBrace(s string) string {
+	switch {
+	case isBraced(s), len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"':
+		return s
+	case !strings.ContainsAny(s, ";{}") && strings.TrimSpace(s) == s:
+		return s
+	}
+	return "{" + strings.ReplaceAll(s, "}", "}}") + "}"
+}
+
+// isBraced returns true when s is enclosed in braces with all inner closing
+// braces doubled.
+func isBraced(s string) bool {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return false
+	}
+	inner := s[1 : len(s)-1]
+	return !strings.Contains(strings.ReplaceAll(inner, "}}", ""), "}")
+}
+
+// escapeUserinfo percent encodes the backslashes in the user info of a URL
+// string, allowing domain accounts (ie, "ms://DOMAIN\user:pass@host") to be
+// used without escaping the backslash, as backslashes are otherwise rejected
+// by [url.Parse].
+func escapeUserinfo(urlstr string) string {
+	i := strings.Index(urlstr, "://")
+	if i == -1 {
+		return urlstr
+	}
+	authority := urlstr[i+3:]
+	if j := strings.IndexAny(authority, "/?#"); j != -1 {
+		authority = authority[:j]
+	}
+	j := strings.LastIndex(authority, "@")
+	if j == -1 || !strings.Contains(authority[:j], `\`) {
+		return urlstr
+	}
+	return urlstr[:i+3] + strings.ReplaceAll(authority[:j], `\`, "%5C") + urlstr[i+3+j:]
+}
@@ -0,0 +1,333 @@
+package dburl
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// FromDSN converts a native driver DSN into a URL for the driver's scheme
+// (ie, the "postgres" scheme for a lib/pq DSN). Allows configs written for a
+// specific driver to be used in the normalized URL form. Supports the DSN
+// formats of the following drivers (and schemes using them):
+//
+//	postgres, pgx   libpq "key=value" keywords, or "postgres://" URLs
+//	mysql           go-sql-driver "[user[:pass]@][net[(addr)]]/dbname[?params]"
+//	sqlserver       ADO "key=value;" strings, or "sqlserver://" URLs
+//
+// Parameters without an equivalent URL component are passed through as query
+// parameters, and libpq host and port lists (ie, "host=h1,h2 port=5432,5433")
+// are paired as multiple hosts. Returns [ErrUnsupportedDSN] for other drivers,
+// and [ErrInvalidDSN] (wrapping the underlying error, if any) when the DSN
+// cannot be parsed or converted.
+func FromDSN(driver, dsn string) (*URL, error) {
+	scheme, ok := lookupScheme(driver)
+	if !ok {
+		return nil, ErrUnknownDatabaseScheme
+	}
+	var z *url.URL
+	var err error
+	switch goDriver(scheme) {
+	case "postgres", "pgx":
+		z, err = fromPostgresDSN(dsn)
+	case "mysql":
+		z, err = fromMysqlDSN(dsn)
+	case "sqlserver", "azuresql":
+		z, err = fromSqlserverDSN(dsn)
+	default:
+		return nil, ErrUnsupportedDSN
+	}
+	if err != nil {
+		return nil, err
+	}
+	z.Scheme = scheme.Driver
+	u, err := Parse(z.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDSN, err)
+	}
+	return u, nil
+}
+
+// fromPostgresDSN converts a libpq "key=value" DSN, or a "postgres://" URL.
+func fromPostgresDSN(dsn string) (*url.URL, error) {
+	if s := strings.ToLower(dsn); strings.HasPrefix(s, "postgres://") || strings.HasPrefix(s, "postgresql://") {
+		return parseDSNURL(dsn)
+	}
+	q, err := parseKeywords(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if !q.Has("host") && q.Has("hostaddr") {
+		q.Set("host", q.Get("hostaddr"))
+	}
+	q.Del("hostaddr")
+	z := &url.URL{
+		User: userinfo(pop(q, "user"), q, "password"),
+		Path: "/" + pop(q, "dbname"),
+	}
+	switch host := q.Get("host"); {
+	case strings.HasPrefix(host, "/"), strings.HasPrefix(host, "@"):
+		// socket directory (ie, "host=/var/run/postgresql"), kept in the query
+	default:
+		// lib/pq's default host is localhost
+		if z.Host, err = pairHostPorts(defaultString(host, "localhost"), q.Get("port")); err != nil {
+			return nil, err
+		}
+		q.Del("host")
+		q.Del("port")
+	}
+	z.RawQuery = q.Encode()
+	return z, nil
+}
+
+// pairHostPorts pairs each host of a libpq comma separated host list (ie,
+// "h1,h2") with its port from the port list (ie, "5432,5433"), returning the
+// joined "host:port" list. A single port is used for all hosts.
+func pairHostPorts(host, port string) (string, error) {
+	hosts, ports := strings.Split(host, ","), strings.Split(port, ",")
+	if len(ports) != 1 && len(ports) != len(hosts) {
+		return "", ErrInvalidDSN
+	}
+	v := make([]string, len(hosts))
+	for i, h := range hosts {
+		p := ports[0]
+		if len(ports) != 1 {
+			p = ports[i]
+		}
+		if h = strings.TrimSpace(h); h == "" || strings.HasPrefix(h, "/") {
+			return "", ErrInvalidDSN
+		}
+		v[i] = joinHostPort(h, strings.TrimSpace(p))
+	}
+	return strings.Join(v, ","), nil
+}
+
+// parseKeywords parses a libpq "key=value" DSN, where values may be single
+// quoted using backslash escapes (ie, "password='my pass'").
+func parseKeywords(dsn string) (url.Values, error) {
+	q := make(url.Values)
+	for s := strings.TrimSpace(dsn); s != ""; s = strings.TrimLeftFunc(s, unicode.IsSpace) {
+		i := strings.IndexRune(s, '=')
+		if i <= 0 {
+			return nil, ErrInvalidDSN
+		}
+		key := strings.TrimSpace(s[:i])
+		s = strings.TrimLeftFunc(s[i+1:], unicode.IsSpace)
+		var val strings.Builder
+		switch {
+		case strings.HasPrefix(s, "'"):
+			j := 1
+			for ; j < len(s) && s[j] != '\''; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				val.WriteByte(s[j])
+			}
+			if j == len(s) {
+				return nil, ErrInvalidDSN
+			}
+			s = s[j+1:]
+		default:
+			j := strings.IndexFunc(s, unicode.IsSpace)
+			if j == -1 {
+				j = len(s)
+			}
+			val.WriteString(s[:j])
+			s = s[j:]
+		}
+		q.Set(key, val.String())
+	}
+	return q, nil
+}
+
+// fromMysqlDSN converts a go-sql-driver mysql
+// "[user[:pass]@][net[(addr)]]/dbname[?params]" DSN.
+func fromMysqlDSN(dsn string) (*url.URL, error) {
+	dsn, rawQuery, _ := strings.Cut(dsn, "?")
+	i := strings.LastIndex(dsn, "/")
+	if i == -1 {
+		return nil, ErrInvalidDSN
+	}
+	prefix, dbname := dsn[:i], dsn[i+1:]
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, ErrInvalidDSN
+	}
+	z := &url.URL{
+		Path: "/" + dbname,
+	}
+	// user and password
+	if j := strings.LastIndex(prefix, "@"); j != -1 {
+		user, pass, ok := strings.Cut(prefix[:j], ":")
+		switch {
+		case ok:
+			z.User = url.UserPassword(user, pass)
+		case user != "":
+			z.User = url.User(user)
+		}
+		prefix = prefix[j+1:]
+	}
+	// network and address
+	network, addr := prefix, ""
+	if j := strings.IndexRune(prefix, '('); j != -1 {
+		if !strings.HasSuffix(prefix, ")") {
+			return nil, ErrInvalidDSN
+		}
+		network, addr = prefix[:j], prefix[j+1:len(prefix)-1]
+	}
+	switch network {
+	case "unix":
+		q.Set("socket", addr)
+	case "", "tcp":
+		// the driver's default address is localhost:3306
+		z.Host = defaultString(addr, "localhost")
+	default:
+		return nil, ErrInvalidDSN
+	}
+	z.RawQuery = q.Encode()
+	return z, nil
+}
+
+// sqlserverKeys are the ADO connection string keys with an equivalent URL
+// component.
+var sqlserverKeys = map[string]string{
+	"server":          "server",
+	"data source":     "server",
+	"addr":            "server",
+	"address":         "server",
+	"network address": "server",
+	"port":            "port",
+	"user id":         "user",
+	"uid":             "user",
+	"user":            "user",
+	"password":        "password",
+	"pwd":             "password",
+	"database":        "database",
+	"initial catalog": "database",
+}
+
+// fromSqlserverDSN converts a sqlserver ADO "key=value;" DSN, or a
+// "sqlserver://" URL.
+func fromSqlserverDSN(dsn string) (*url.URL, error) {
+	if strings.HasPrefix(strings.ToLower(dsn), "sqlserver://") {
+		return parseDSNURL(dsn)
+	}
+	q, m := make(url.Values), make(map[string]string)
+	pairs, err := splitADO(dsn)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range pairs {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, ErrInvalidDSN
+		}
+		k, v = strings.TrimSpace(k), unquoteADO(strings.TrimSpace(v))
+		if key, ok := sqlserverKeys[strings.ToLower(k)]; ok {
+			m[key] = v
+		} else {
+			q.Set(k, v)
+		}
+	}
+	// server is "[tcp:]host[\instance][,port]"
+	host, port, _ := strings.Cut(strings.TrimPrefix(m["server"], "tcp:"), ",")
+	host, instance, _ := strings.Cut(host, `\`)
+	if port == "" {
+		port = m["port"]
+	}
+	z := &url.URL{
+		Host: joinHostPort(host, port),
+	}
+	if pass, ok := m["password"]; ok {
+		z.User = url.UserPassword(m["user"], pass)
+	} else if m["user"] != "" {
+		z.User = url.User(m["user"])
+	}
+	switch db := m["database"]; {
+	case instance != "":
+		z.Path = "/" + instance + "/" + db
+	case db != "":
+		z.Path = "/" + db
+	}
+	z.RawQuery = q.Encode()
+	return z, nil
+}
+
+// splitADO splits an ADO "key=value;" DSN into its non-empty "key=value"
+// pairs, where values may contain semicolons when surrounded by braces or
+// double quotes.
+func splitADO(dsn string) ([]string, error) {
+	var pairs []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(dsn); i++ {
+		switch c := dsn[i]; {
+		case quote == '}' && c == '}' && i+1 < len(dsn) && dsn[i+1] == '}',
+			quote == '"' && c == '"' && i+1 < len(dsn) && dsn[i+1] == '"':
+			// escaped closing brace or double quote
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case (c == '{' || c == '"') && strings.HasSuffix(strings.TrimSpace(dsn[start:i]), "="):
+			// opening brace or double quote of a value
+			if quote = '"'; c == '{' {
+				quote = '}'
+			}
+		case c == ';':
+			if s := dsn[start:i]; strings.TrimSpace(s) != "" {
+				pairs = append(pairs, s)
+			}
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, ErrInvalidDSN
+	}
+	if s := dsn[start:]; strings.TrimSpace(s) != "" {
+		pairs = append(pairs, s)
+	}
+	return pairs, nil
+}
+
+// unquoteADO removes the braces or double quotes surrounding an ADO value.
+func unquoteADO(s string) string {
+	switch {
+	case isBraced(s):
+		return strings.ReplaceAll(s[1:len(s)-1], "}}", "}")
+	case len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"':
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
+
+// parseDSNURL parses a URL style DSN.
+func parseDSNURL(dsn string) (*url.URL, error) {
+	z, err := url.Parse(dsn)
+	if err != nil {
+		return nil, ErrInvalidDSN
+	}
+	return z, nil
+}
+
+// pop returns the value of key in q, deleting the key.
+func pop(q url.Values, key string) string {
+	v := q.Get(key)
+	q.Del(key)
+	return v
+}
+
+// userinfo returns the user info for the user, with the password from key in
+// q (deleting the key), if any.
+func userinfo(user string, q url.Values, key string) *url.Userinfo {
+	pass, ok := q.Get(key), q.Has(key)
+	q.Del(key)
+	switch {
+	case ok:
+		return url.UserPassword(user, pass)
+	case user != "":
+		return url.User(user)
+	}
+	return nil
+}
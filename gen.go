@@ -0,0 +1,506 @@
+//go:build ignore
+
+// Command gen generates the built-in schemes (zz_schemes.go) and the
+// built-in scheme lookup (zz_lookup.go) from the declarative scheme
+// definitions in schemes.yaml.
+//
+// Only the subset of YAML used by schemes.yaml is supported: a sequence of
+// mappings with plain or double quoted scalars, flow sequences ("[a, b]"),
+// flow mappings ("{a: b}"), and a single level of nested block mappings.
+//
+// Run with:
+//
+//	go generate
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	if err := run("schemes.yaml", "zz_schemes.go", "zz_lookup.go"); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run reads the scheme definitions from the in file, and generates the
+// schemes and lookup files.
+func run(in, schemesOut, lookupOut string) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	schemes, err := readSchemes(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	names, err := schemeNames(schemes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	if err := write(schemesOut, genSchemes(schemes)); err != nil {
+		return err
+	}
+	return write(lookupOut, genLookup(names))
+}
+
+// write formats and writes the generated source to the out file.
+func write(out string, buf []byte) error {
+	src, err := format.Source(buf)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
+
+// scheme is a scheme definition.
+type scheme struct {
+	comments             []string
+	driver               string
+	generator            string
+	template             string
+	transports           []string
+	opaque               bool
+	aliases              []string
+	override             string
+	defaultPort          string
+	httpPort             string
+	defaultUser          string
+	pkg                  string
+	requiresCGO          bool
+	pureGoAlternative    string
+	placeholder          string
+	identQuote           string
+	pingQuery            string
+	versionQuery         string
+	databasesQuery       string
+	schemasQuery         string
+	currentDatabaseQuery string
+	examples             []string
+	tags                 []string
+	param                string
+	values               [][2]string
+}
+
+// readSchemes reads the scheme definitions.
+func readSchemes(r io.Reader) ([]*scheme, error) {
+	var schemes []*scheme
+	var cur *scheme
+	var comments []string
+	var nested string
+	s := bufio.NewScanner(r)
+	for i := 1; s.Scan(); i++ {
+		line := s.Text()
+		switch z := strings.TrimSpace(line); {
+		case z == "":
+			comments = nil
+			continue
+		case strings.HasPrefix(z, "#"):
+			comments = append(comments, strings.TrimSpace(strings.TrimPrefix(z, "#")))
+			continue
+		}
+		if strings.HasPrefix(line, "- ") {
+			cur, comments = &scheme{comments: comments}, nil
+			schemes = append(schemes, cur)
+			line = "  " + line[2:]
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("line %d: expected scheme", i)
+		}
+		k, v, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid line", i)
+		}
+		v = strings.TrimSpace(v)
+		var err error
+		switch indent := len(line) - len(strings.TrimLeft(line, " ")); {
+		case indent == 2 && v == "":
+			nested = k
+		case indent == 2:
+			nested, err = "", cur.set(k, v)
+		case indent == 4 && nested != "":
+			err = cur.set(nested+"."+k, v)
+		default:
+			err = fmt.Errorf("invalid indent")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	for _, scheme := range schemes {
+		if err := scheme.validate(); err != nil {
+			return nil, fmt.Errorf("scheme %s: %w", scheme.driver, err)
+		}
+	}
+	return schemes, nil
+}
+
+// set sets the field.
+func (s *scheme) set(k, v string) error {
+	var err error
+	switch k {
+	case "driver":
+		s.driver, err = scalar(v)
+	case "generator":
+		s.generator, err = scalar(v)
+	case "template":
+		s.template, err = scalar(v)
+	case "transports":
+		s.transports, err = flowSeq(v)
+	case "opaque":
+		s.opaque, err = strconv.ParseBool(v)
+	case "aliases":
+		s.aliases, err = flowSeq(v)
+	case "override":
+		s.override, err = scalar(v)
+	case "default_port":
+		s.defaultPort, err = scalar(v)
+	case "http_port":
+		s.httpPort, err = scalar(v)
+	case "default_user":
+		s.defaultUser, err = scalar(v)
+	case "package":
+		s.pkg, err = scalar(v)
+	case "requires_cgo":
+		s.requiresCGO, err = strconv.ParseBool(v)
+	case "pure_go_alternative":
+		s.pureGoAlternative, err = scalar(v)
+	case "placeholder":
+		s.placeholder, err = scalar(v)
+	case "ident_quote":
+		s.identQuote, err = scalar(v)
+	case "ping_query":
+		s.pingQuery, err = scalar(v)
+	case "version_query":
+		s.versionQuery, err = scalar(v)
+	case "databases_query":
+		s.databasesQuery, err = scalar(v)
+	case "schemas_query":
+		s.schemasQuery, err = scalar(v)
+	case "current_database_query":
+		s.currentDatabaseQuery, err = scalar(v)
+	case "examples":
+		s.examples, err = flowSeq(v)
+	case "tags":
+		s.tags, err = flowSeq(v)
+	case "transport_param.param":
+		s.param, err = scalar(v)
+	case "transport_param.values":
+		s.values, err = flowMap(v)
+	default:
+		return fmt.Errorf("unknown field %q", k)
+	}
+	return err
+}
+
+// validate validates the scheme definition.
+func (s *scheme) validate() error {
+	switch {
+	case s.driver == "":
+		return fmt.Errorf("missing driver")
+	case s.generator == "":
+		return fmt.Errorf("missing generator")
+	case (s.generator == "url" || s.generator == "scheme") && s.template == "":
+		return fmt.Errorf("missing template")
+	case s.param == "" && len(s.values) != 0:
+		return fmt.Errorf("missing transport param")
+	case s.placeholder != "" && placeholders[s.placeholder] == "":
+		return fmt.Errorf("unknown placeholder %q", s.placeholder)
+	case s.identQuote != "" && identQuotes[s.identQuote] == "":
+		return fmt.Errorf("unknown identifier quote %q", s.identQuote)
+	case s.requiresCGO != slices.Contains(s.tags, "cgo"):
+		return fmt.Errorf("requires_cgo does not match cgo tag")
+	case s.pureGoAlternative != "" && !s.requiresCGO:
+		return fmt.Errorf("pure_go_alternative requires requires_cgo")
+	}
+	for _, t := range s.transports {
+		if transports[t] == "" {
+			return fmt.Errorf("unknown transport %q", t)
+		}
+		if t == "unix" && s.opaque {
+			return fmt.Errorf("scheme must support only Opaque or Unix protocols, not both")
+		}
+	}
+	return nil
+}
+
+// transports are the transport policy names.
+var transports = map[string]string{
+	"tcp":   "TransportTCP",
+	"udp":   "TransportUDP",
+	"unix":  "TransportUnix",
+	"any":   "TransportAny",
+	"named": "TransportNamed",
+}
+
+// placeholders are the placeholder style names.
+var placeholders = map[string]string{
+	"question": "PlaceholderQuestion",
+	"dollar":   "PlaceholderDollar",
+	"colon":    "PlaceholderColon",
+	"at":       "PlaceholderAt",
+}
+
+// identQuotes are the identifier quoting style names.
+var identQuotes = map[string]string{
+	"double":   "IdentQuoteDouble",
+	"backtick": "IdentQuoteBacktick",
+	"bracket":  "IdentQuoteBracket",
+}
+
+// scalar parses a plain or double quoted scalar.
+func scalar(v string) (string, error) {
+	if strings.HasPrefix(v, `"`) {
+		return strconv.Unquote(v)
+	}
+	return v, nil
+}
+
+// flowSeq parses a flow sequence of scalars.
+func flowSeq(v string) ([]string, error) {
+	if !strings.HasPrefix(v, "[") || !strings.HasSuffix(v, "]") {
+		return nil, fmt.Errorf("invalid sequence %q", v)
+	}
+	var seq []string
+	for _, z := range strings.Split(v[1:len(v)-1], ",") {
+		if z = strings.TrimSpace(z); z == "" {
+			continue
+		}
+		s, err := scalar(z)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, s)
+	}
+	return seq, nil
+}
+
+// flowMap parses a flow mapping of scalars, preserving order.
+func flowMap(v string) ([][2]string, error) {
+	if !strings.HasPrefix(v, "{") || !strings.HasSuffix(v, "}") {
+		return nil, fmt.Errorf("invalid mapping %q", v)
+	}
+	var m [][2]string
+	for _, z := range strings.Split(v[1:len(v)-1], ",") {
+		if z = strings.TrimSpace(z); z == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(z, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping entry %q", z)
+		}
+		ks, err := scalar(strings.TrimSpace(k))
+		if err != nil {
+			return nil, err
+		}
+		vs, err := scalar(strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, [2]string{ks, vs})
+	}
+	return m, nil
+}
+
+// schemeNames returns the driver name and aliases (including the implicit 2
+// letter alias) of each scheme, as registered by Register, returning an error
+// when a name is used by more than one scheme.
+func schemeNames(schemes []*scheme) ([][]string, error) {
+	seen := make(map[string]string)
+	names := make([][]string, len(schemes))
+	for i, scheme := range schemes {
+		v := []string{scheme.driver}
+		var hasShort bool
+		for _, alias := range scheme.aliases {
+			if len(alias) == 2 {
+				hasShort = true
+			}
+			if alias != scheme.driver {
+				v = append(v, alias)
+			}
+		}
+		if !hasShort && len(scheme.driver) > 2 {
+			v = append(v, scheme.driver[:2])
+		}
+		for _, name := range v {
+			if driver, ok := seen[name]; ok {
+				return nil, fmt.Errorf("scheme %s: %s already registered by scheme %s", scheme.driver, name, driver)
+			}
+			seen[name] = scheme.driver
+		}
+		names[i] = v
+	}
+	return names, nil
+}
+
+// genSchemes generates BaseSchemes.
+func genSchemes(schemes []*scheme) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString(`// Code generated by gen.go. DO NOT EDIT.
+
+package dburl
+
+// BaseSchemes returns the supported base schemes.
+func BaseSchemes() []Scheme {
+	return []Scheme{
+`)
+	for _, s := range schemes {
+		for _, comment := range s.comments {
+			fmt.Fprintf(buf, "// %s\n", comment)
+		}
+		fmt.Fprintf(buf, "{\nDriver: %q,\n", s.driver)
+		switch s.generator {
+		case "opaque":
+			buf.WriteString("Generator: GenOpaque,\n")
+		case "url":
+			fmt.Fprintf(buf, "Generator: GenFromURL(%q),\n", s.template)
+		case "scheme":
+			fmt.Fprintf(buf, "Generator: GenScheme(%q),\n", s.template)
+		default:
+			fmt.Fprintf(buf, "Generator: %s,\n", s.generator)
+		}
+		fmt.Fprintf(buf, "manifestGenerator: %q,\n", s.manifestGenerator())
+		if len(s.transports) != 0 {
+			v := make([]string, len(s.transports))
+			for i, t := range s.transports {
+				v[i] = transports[t]
+			}
+			fmt.Fprintf(buf, "Transport: %s,\n", strings.Join(v, " | "))
+		}
+		if s.opaque {
+			buf.WriteString("Opaque: true,\n")
+		}
+		if len(s.aliases) != 0 {
+			fmt.Fprintf(buf, "Aliases: []string{%s},\n", quote(s.aliases))
+		}
+		if s.override != "" {
+			fmt.Fprintf(buf, "Override: %q,\n", s.override)
+		}
+		if s.defaultPort != "" {
+			fmt.Fprintf(buf, "DefaultPort: %q,\n", s.defaultPort)
+		}
+		if s.httpPort != "" {
+			fmt.Fprintf(buf, "HTTPPort: %q,\n", s.httpPort)
+		}
+		if s.defaultUser != "" {
+			fmt.Fprintf(buf, "DefaultUser: %q,\n", s.defaultUser)
+		}
+		if s.pkg != "" {
+			fmt.Fprintf(buf, "Package: %q,\n", s.pkg)
+		}
+		if s.requiresCGO {
+			buf.WriteString("RequiresCGO: true,\n")
+		}
+		if s.pureGoAlternative != "" {
+			fmt.Fprintf(buf, "PureGoAlternative: %q,\n", s.pureGoAlternative)
+		}
+		if s.placeholder != "" {
+			fmt.Fprintf(buf, "Placeholder: %s,\n", placeholders[s.placeholder])
+		}
+		if s.identQuote != "" {
+			fmt.Fprintf(buf, "IdentQuote: %s,\n", identQuotes[s.identQuote])
+		}
+		if s.pingQuery != "" {
+			fmt.Fprintf(buf, "PingQuery: %q,\n", s.pingQuery)
+		}
+		if s.versionQuery != "" {
+			fmt.Fprintf(buf, "VersionQuery: %q,\n", s.versionQuery)
+		}
+		if s.databasesQuery != "" {
+			fmt.Fprintf(buf, "DatabasesQuery: %q,\n", s.databasesQuery)
+		}
+		if s.schemasQuery != "" {
+			fmt.Fprintf(buf, "SchemasQuery: %q,\n", s.schemasQuery)
+		}
+		if s.currentDatabaseQuery != "" {
+			fmt.Fprintf(buf, "CurrentDatabaseQuery: %q,\n", s.currentDatabaseQuery)
+		}
+		if len(s.examples) != 0 {
+			fmt.Fprintf(buf, "Examples: []string{%s},\n", quote(s.examples))
+		}
+		if len(s.tags) != 0 {
+			fmt.Fprintf(buf, "Tags: []string{%s},\n", quote(s.tags))
+		}
+		if s.param != "" {
+			v := make([]string, len(s.values))
+			for i, kv := range s.values {
+				v[i] = strconv.Quote(kv[0]) + ": " + strconv.Quote(kv[1])
+			}
+			fmt.Fprintf(buf, "TransportParam: &TransportParam{\nParam: %q,\nValues: map[string]string{%s},\n},\n", s.param, strings.Join(v, ", "))
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n}\n")
+	buf.WriteString(`
+// builtinGenerators are the generators of the built-in schemes, by name, as
+// used in scheme manifests. See [LoadSchemes].
+var builtinGenerators = map[string]func(*URL) (string, string, error){
+`)
+	var names []string
+	for _, s := range schemes {
+		if name := s.manifestGenerator(); strings.HasPrefix(name, "Gen") && !strings.Contains(name, ":") && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "%q: %s,\n", name, name)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// manifestGenerator returns the scheme's generator, as used in scheme
+// manifests: a URL template, "GenScheme:<scheme>", or the generator's name.
+func (s *scheme) manifestGenerator() string {
+	switch s.generator {
+	case "opaque":
+		return "GenOpaque"
+	case "url":
+		return s.template
+	case "scheme":
+		return "GenScheme:" + s.template
+	}
+	return s.generator
+}
+
+// genLookup generates builtinIndex.
+func genLookup(names [][]string) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString(`// Code generated by gen.go. DO NOT EDIT.
+
+package dburl
+
+// builtinIndex returns the index in [BaseSchemes] of the built-in scheme with
+// the name or alias, or -1 when not a built-in scheme name or alias.
+func builtinIndex(name string) int {
+	switch name {
+`)
+	for i, v := range names {
+		fmt.Fprintf(buf, "case %s:\nreturn %d\n", quote(v), i)
+	}
+	buf.WriteString("}\nreturn -1\n}\n")
+	return buf.Bytes()
+}
+
+// quote quotes and joins the strings.
+func quote(v []string) string {
+	z := make([]string, len(v))
+	for i, s := range v {
+		z[i] = strconv.Quote(s)
+	}
+	return strings.Join(z, ", ")
+}
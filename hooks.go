@@ -0,0 +1,144 @@
+package dburl
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+)
+
+// OpenFunc is a func that opens a standard [sql.DB] connection for a parsed
+// [URL].
+type OpenFunc func(*URL) (*sql.DB, error)
+
+// OpenHook is a hook that wraps the [OpenFunc] used by [Open].
+//
+// Open hooks allow functionality that requires external dependencies (for
+// example, SSH tunnels, cloud connectors, or instrumentation) to be provided
+// by opt-in packages, keeping this package free of dependencies outside of
+// the standard library. Such packages should register their hooks using
+// [RegisterOpenHook] in an init func.
+type OpenHook func(next OpenFunc) OpenFunc
+
+// openHooks are the registered open hooks.
+var openHooks struct {
+	sync.RWMutex
+	hooks []*OpenHook
+}
+
+// RegisterOpenHook registers an open hook. Hooks are applied in the order
+// registered, with the last registered hook being called first.
+//
+// Returns a func that unregisters the hook, for use by tests, or by packages
+// that only temporarily wrap [Open].
+func RegisterOpenHook(hook OpenHook) func() {
+	if hook == nil {
+		panic("must specify a non-nil OpenHook")
+	}
+	h := &hook
+	openHooks.Lock()
+	defer openHooks.Unlock()
+	openHooks.hooks = append(openHooks.hooks, h)
+	return func() {
+		openHooks.Lock()
+		defer openHooks.Unlock()
+		openHooks.hooks = slices.DeleteFunc(openHooks.hooks, func(z *OpenHook) bool {
+			return z == h
+		})
+	}
+}
+
+// OpenURL opens a standard [sql.DB] connection for a parsed [URL], applying
+// any registered open hooks. Credentials are read from the URL's
+// "credentials_dir", the host of "+srv" URLs is resolved, and the host is
+// resolved to the IP family of the "ip" query parameter, prior to opening.
+// See [ResolveCredentials], [ResolveSRV], and [ResolveIP].
+//
+// The returned database's connection pool is configured with the URL's
+// "dburl_max_open_conns", "dburl_max_idle_conns", "dburl_conn_max_lifetime",
+// and "dburl_conn_max_idle_time" query parameters, if any.
+func OpenURL(u *URL) (*sql.DB, error) {
+	u, err := ResolveCredentials(u)
+	if err != nil {
+		return nil, err
+	}
+	if u, err = ResolveSRV(context.Background(), u); err != nil {
+		return nil, err
+	}
+	if u, err = ResolveIP(context.Background(), u); err != nil {
+		return nil, err
+	}
+	if u.opts != nil && u.opts.requireSecure && hasProblem(u.Diagnostics, ProblemPlaintextCredentials) {
+		return nil, ErrInsecureConnection
+	}
+	openHooks.RLock()
+	f := OpenFunc(openURL)
+	for _, hook := range openHooks.hooks {
+		f = (*hook)(f)
+	}
+	openHooks.RUnlock()
+	db, err := f(u)
+	if err != nil {
+		return nil, err
+	}
+	configurePool(db, u)
+	incStat(StatOpen, u.Driver)
+	return db, nil
+}
+
+// openURL opens a standard [sql.DB] connection for a parsed [URL].
+func openURL(u *URL) (*sql.DB, error) {
+	driver := u.Driver
+	if u.GoDriver != "" {
+		driver = u.GoDriver
+	}
+	switch z, err := fallbackURL(u, driver); {
+	case err != nil:
+		return nil, err
+	case z != nil:
+		return openURL(z)
+	}
+	if err := prepareOpen(u); err != nil {
+		return nil, err
+	}
+	if u.Driver == "duckdb" && len(u.Attachments()) != 0 {
+		return openAttach(driver, u)
+	}
+	return sql.Open(driver, u.DSN)
+}
+
+// prepareOpen validates the URL's client certificate and key (when parsed
+// with [WithClientCertValidation]), and registers the URL's TLS config with
+// the driver, prior to opening the URL.
+func prepareOpen(u *URL) error {
+	if u.opts != nil && u.opts.validateCerts {
+		if err := ValidateClientCert(u); err != nil {
+			return err
+		}
+	}
+	return registerTLSConfig(u)
+}
+
+// fallbackURL returns a new URL for the scheme's pure Go alternative when the
+// URL's Go SQL driver requires CGO and is not available, and the URL was
+// parsed with [AllowFallbackDrivers]. Returns a [DriverError] when the driver
+// is not available and the URL cannot fall back, or nil when the driver is
+// available.
+func fallbackURL(u *URL, driver string) (*URL, error) {
+	scheme := u.goDriverScheme()
+	if scheme == nil || !scheme.RequiresCGO || contains(sql.Drivers(), driver) {
+		return nil, nil
+	}
+	err := &DriverError{Driver: driver}
+	alt, ok := lookupScheme(scheme.PureGoAlternative)
+	if !ok || !contains(sql.Drivers(), goDriver(alt)) {
+		return nil, err
+	}
+	err.Alternative = alt.Driver
+	if u.opts == nil || !u.opts.fallbackDrivers {
+		return nil, err
+	}
+	z := u.URL
+	z.Scheme = alt.Driver
+	return parse((&URL{URL: z, OriginalScheme: alt.Driver}).String(), u.opts)
+}
@@ -0,0 +1,78 @@
+package dburl
+
+import (
+	"strconv"
+	"strings"
+)
+
+// IdentQuote is an identifier quoting style.
+type IdentQuote uint
+
+// Identifier quoting styles.
+const (
+	// IdentQuoteDefault is the default identifier quoting style, which is the
+	// style of the scheme's Override, or IdentQuoteDouble.
+	IdentQuoteDefault IdentQuote = iota
+	// IdentQuoteDouble is the `"ident"` (SQL standard) quoting style.
+	IdentQuoteDouble
+	// IdentQuoteBacktick is the "`ident`" quoting style.
+	IdentQuoteBacktick
+	// IdentQuoteBracket is the "[ident]" quoting style.
+	IdentQuoteBracket
+)
+
+// identQuoteNames are the names of the identifier quoting styles.
+var identQuoteNames = []string{
+	IdentQuoteDefault:  "",
+	IdentQuoteDouble:   "double",
+	IdentQuoteBacktick: "backtick",
+	IdentQuoteBracket:  "bracket",
+}
+
+// String satisfies the [fmt.Stringer] interface.
+func (q IdentQuote) String() string {
+	if int(q) < len(identQuoteNames) {
+		return identQuoteNames[q]
+	}
+	return "IdentQuote(" + strconv.Itoa(int(q)) + ")"
+}
+
+// Quote quotes the identifier, escaping any closing quote characters.
+func (q IdentQuote) Quote(ident string) string {
+	switch q {
+	case IdentQuoteBacktick:
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	case IdentQuoteBracket:
+		return "[" + strings.ReplaceAll(ident, "]", "]]") + "]"
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// parseIdentQuote parses the identifier quoting style name.
+func parseIdentQuote(name string) (IdentQuote, bool) {
+	for i, s := range identQuoteNames {
+		if strings.EqualFold(name, s) {
+			return IdentQuote(i), true
+		}
+	}
+	return IdentQuoteDefault, false
+}
+
+// IdentQuote returns the identifier quoting style of the URL's driver.
+func (u *URL) IdentQuote() IdentQuote {
+	scheme := u.driverScheme(func(scheme *Scheme) bool {
+		return scheme.IdentQuote != IdentQuoteDefault
+	})
+	if scheme == nil || scheme.IdentQuote == IdentQuoteDefault {
+		return IdentQuoteDouble
+	}
+	return scheme.IdentQuote
+}
+
+// QuoteIdentifier quotes the identifier using the identifier quoting style of
+// the URL's driver (ie, "`ident`" for mysql, "[ident]" for sqlserver, and
+// `"ident"` otherwise). The identifier is quoted as a single identifier, and
+// is not split on ".".
+func QuoteIdentifier(u *URL, ident string) string {
+	return u.IdentQuote().Quote(ident)
+}
@@ -0,0 +1,73 @@
+package dburl
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// ipParam is the query parameter specifying the preferred IP family ("4",
+// "6", or "any") of the URL's host.
+const ipParam = "ip"
+
+// LookupIP is the default IP lookup func.
+//
+// Used internally to resolve the host of URLs with the "ip" query parameter
+// to an address of the preferred IP family. Can be overridden to use a
+// different resolver, or in tests.
+var LookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, network, host)
+}
+
+// ipNetwork returns the IP network ("ip4" or "ip6") for the URL's "ip" query
+// parameter, if any.
+func ipNetwork(u *URL) string {
+	switch u.Query().Get(ipParam) {
+	case "4":
+		return "ip4"
+	case "6":
+		return "ip6"
+	}
+	return ""
+}
+
+// ResolveIP returns a new URL with the host resolved to an address literal of
+// the IP family specified by the URL's "ip" query parameter ("4" or "6"),
+// using [LookupIP]. Useful in dual-stack environments for databases that do
+// not listen on IPv6, as drivers do not expose their dialer. When the URL
+// does not specify an IP family, does not have a network host, or the host
+// is already an address literal, the URL is returned unchanged.
+//
+// As the host is replaced with an address literal, drivers verifying the
+// server's TLS certificate against the host (ie, postgres'
+// "sslmode=verify-full") require the certificate to include the address.
+//
+// Called by [OpenURL], so that the current address is used for each Open.
+func ResolveIP(ctx context.Context, u *URL) (*URL, error) {
+	network := ipNetwork(u)
+	host := u.Hostname()
+	if network == "" || u.Transport != "tcp" || host == "" ||
+		strings.Contains(u.Host, ",") || net.ParseIP(host) != nil {
+		return u, nil
+	}
+	ips, err := LookupIP(ctx, network, host)
+	switch {
+	case err != nil:
+		return nil, err
+	case len(ips) == 0:
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	z := u.URL
+	z.Host = ips[0].String()
+	switch port := u.Port(); {
+	case port != "":
+		z.Host = net.JoinHostPort(z.Host, port)
+	case network == "ip6":
+		z.Host = "[" + z.Host + "]"
+	}
+	o := u.opts
+	if o == nil {
+		o = newOptions(ctx)
+	}
+	return parse((&URL{URL: z, OriginalScheme: u.OriginalScheme}).String(), o)
+}
@@ -0,0 +1,197 @@
+package dburl
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ProblemCode is a [Lint] problem code.
+type ProblemCode string
+
+// Problem codes.
+const (
+	// ProblemInvalid is the invalid URL problem code.
+	ProblemInvalid ProblemCode = "invalid"
+	// ProblemPlaintextPassword is the plaintext password problem code.
+	ProblemPlaintextPassword ProblemCode = "plaintext-password"
+	// ProblemMissingTLS is the missing TLS parameter problem code.
+	ProblemMissingTLS ProblemCode = "missing-tls"
+	// ProblemDeprecatedAlias is the deprecated alias problem code.
+	ProblemDeprecatedAlias ProblemCode = "deprecated-alias"
+	// ProblemUnknownOption is the unknown option problem code.
+	ProblemUnknownOption ProblemCode = "unknown-option"
+	// ProblemDefaultPort is the explicit default port problem code.
+	ProblemDefaultPort ProblemCode = "default-port"
+	// ProblemPlaintextCredentials is the credentials sent over a plaintext
+	// connection problem code.
+	ProblemPlaintextCredentials ProblemCode = "plaintext-credentials"
+)
+
+// Problem is a non-fatal issue with a URL reported by [Lint].
+type Problem struct {
+	Code    ProblemCode
+	Message string
+}
+
+// String satisfies the [fmt.Stringer] interface.
+func (p Problem) String() string {
+	return string(p.Code) + ": " + p.Message
+}
+
+// Lint parses the URL string and reports any non-fatal problems with it, such
+// as a plaintext password, a missing TLS parameter for a well-known cloud
+// host, a deprecated scheme alias, an unknown driver option, an explicitly
+// specified default port, or the problems found when parsing (see
+// [URL.Diagnostics]).
+//
+// When the URL cannot be parsed, a single [ProblemInvalid] problem is
+// returned.
+func Lint(urlstr string) []Problem {
+	u, err := Parse(urlstr)
+	if err != nil {
+		return []Problem{{ProblemInvalid, err.Error()}}
+	}
+	return u.Lint()
+}
+
+// Lint reports any non-fatal problems with the URL. See [Lint].
+func (u *URL) Lint() []Problem {
+	var problems []Problem
+	add := func(code ProblemCode, format string, v ...interface{}) {
+		problems = append(problems, Problem{code, fmt.Sprintf(format, v...)})
+	}
+	// check password
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			add(ProblemPlaintextPassword, "password for user %q is specified in plaintext", u.User.Username())
+		}
+	}
+	// check alias
+	if scheme, ok := deprecatedAliases[strings.ToLower(u.Scheme)]; ok {
+		add(ProblemDeprecatedAlias, "scheme alias %q is deprecated, use %q", u.Scheme, scheme)
+	}
+	q := u.Query()
+	// check tls on cloud hosts
+	if host := strings.ToLower(u.Hostname()); host != "" && hasSuffix(host, cloudHostSuffixes) && !hasAnyKey(q, tlsParams) {
+		add(ProblemMissingTLS, "host %q is a cloud host, but no TLS parameter (%s) is specified", u.Hostname(), strings.Join(tlsParams, ", "))
+	}
+	// check options
+	if known, ok := knownOptions[u.UnaliasedDriver]; ok {
+		for _, kv := range u.OrderedQuery() {
+			if !contains(known, kv.Key) && !isPortableParam(kv.Key) {
+				add(ProblemUnknownOption, "option %q is not a known %s option", kv.Key, u.UnaliasedDriver)
+			}
+		}
+	}
+	// check port
+	if scheme, ok := lookupScheme(u.Scheme); ok && u.Transport == "tcp" && scheme.DefaultPort != "" && u.Port() == scheme.DefaultPort {
+		add(ProblemDefaultPort, "port %s is the default port for %s", u.Port(), scheme.Driver)
+	}
+	return append(problems, u.Diagnostics...)
+}
+
+// diagnose sets the URL's diagnostics.
+func (u *URL) diagnose(scheme *Scheme) {
+	u.Diagnostics = nil
+	if isPlaintext(u, scheme) {
+		u.Diagnostics = append(u.Diagnostics, Problem{
+			ProblemPlaintextCredentials,
+			fmt.Sprintf("password for user %q is sent over a plaintext connection, as no TLS parameter (%s) is specified", u.User.Username(), strings.Join(tlsParams, ", ")),
+		})
+	}
+}
+
+// isPlaintext returns true when the URL has a password, and connects to a
+// network database over TCP without requesting TLS.
+func isPlaintext(u *URL, scheme *Scheme) bool {
+	if u.User == nil || scheme.Opaque || scheme.DefaultPort == "" || u.Transport != "tcp" {
+		return false
+	}
+	if _, ok := u.User.Password(); !ok {
+		return false
+	}
+	return !expectsTLS(u)
+}
+
+// deprecatedAliases are deprecated scheme aliases and their replacement.
+var deprecatedAliases = map[string]string{
+	"genji": "chai",
+}
+
+// cloudHostSuffixes are host name suffixes of well-known cloud database
+// services that require TLS.
+var cloudHostSuffixes = []string{
+	".rds.amazonaws.com",
+	".redshift.amazonaws.com",
+	".database.azure.com",
+	".database.windows.net",
+	".cockroachlabs.cloud",
+	".aivencloud.com",
+	".neon.tech",
+	".supabase.co",
+	".psdb.cloud",
+	".clickhouse.cloud",
+}
+
+// tlsParams are query parameters used by drivers to configure TLS.
+var tlsParams = []string{
+	"sslmode",
+	"tls",
+	"encrypt",
+	"ssl",
+	"secure",
+	"tls_ca",
+	"tls_cert",
+	"tls_insecure_skip_verify",
+	"tls_server_name",
+	"tls_min_version",
+}
+
+// knownOptions are the known query options for drivers.
+//
+// Only drivers that reject unknown options are listed (ie, libpq connection
+// keywords for postgres), as other drivers pass unknown options through as
+// session variables.
+var knownOptions = map[string][]string{
+	"postgres": {
+		"application_name", "channel_binding", "client_encoding",
+		"connect_timeout", "datestyle", "dbname", "fallback_application_name",
+		"gssencmode", "host", "hostaddr", "keepalives", "keepalives_count",
+		"keepalives_idle", "keepalives_interval", "krbsrvname", "options",
+		"passfile", "password", "port", "replication", "search_path",
+		"service", "sslcert", "sslcrl", "sslinline", "sslkey", "sslmode",
+		"sslpassword", "sslrootcert", "sslsni", "target_session_attrs",
+		"timezone", "user",
+	},
+}
+
+// hasSuffix returns true when s ends with any listed suffix.
+func hasSuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyKey returns true when q contains any of the keys.
+func hasAnyKey(q url.Values, keys []string) bool {
+	for _, k := range keys {
+		if _, ok := q[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasProblem returns true when the problems contain the problem code.
+func hasProblem(problems []Problem, code ProblemCode) bool {
+	for _, p := range problems {
+		if p.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,453 @@
+package dburl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ManifestScheme is a declarative description of a [Scheme], as read by
+// [LoadSchemes] and written by [DumpSchemes].
+type ManifestScheme struct {
+	// Driver is the scheme's driver name.
+	Driver string `json:"driver"`
+	// Aliases are the scheme's aliases.
+	Aliases []string `json:"aliases,omitempty"`
+	// Override is the Go SQL driver to use instead of Driver.
+	Override string `json:"override,omitempty"`
+	// Generator is either a URL template (ie, "postgres://localhost:6875/"),
+	// generating DSNs as with [GenFromURL], the name of a built-in generator
+	// (ie, "GenPostgres"), "GenScheme:" followed by a scheme, generating DSNs
+	// as with [GenScheme] (ie, "GenScheme:s3"), or the name of a registered
+	// scheme whose generator will be used (ie, "postgres").
+	Generator string `json:"generator,omitempty"`
+	// Transports are the allowed transport names ("tcp", "udp", "unix",
+	// "any", "named"). See [TransportPolicy].
+	Transports []string `json:"transports,omitempty"`
+	// Opaque toggles opaque URL handling for the scheme.
+	Opaque bool `json:"opaque,omitempty"`
+	// DefaultPort is the scheme's default port.
+	DefaultPort string `json:"default_port,omitempty"`
+	// HTTPPort is the scheme's default HTTP API port.
+	HTTPPort string `json:"http_port,omitempty"`
+	// DefaultUser is the scheme's default user name.
+	DefaultUser string `json:"default_user,omitempty"`
+	// Package is the scheme's Go SQL driver import path.
+	Package string `json:"package,omitempty"`
+	// RequiresCGO toggles whether the scheme's Go SQL driver requires CGO.
+	RequiresCGO bool `json:"requires_cgo,omitempty"`
+	// PureGoAlternative is the scheme of a pure Go driver for the same
+	// database.
+	PureGoAlternative string `json:"pure_go_alternative,omitempty"`
+	// Placeholder is the scheme's query placeholder style ("question",
+	// "dollar", "colon", "at"). See [Placeholder].
+	Placeholder string `json:"placeholder,omitempty"`
+	// IdentQuote is the scheme's identifier quoting style ("double",
+	// "backtick", "bracket"). See [IdentQuote].
+	IdentQuote string `json:"ident_quote,omitempty"`
+	// PingQuery is the scheme's connection check query.
+	PingQuery string `json:"ping_query,omitempty"`
+	// VersionQuery is the scheme's server version query.
+	VersionQuery string `json:"version_query,omitempty"`
+	// DatabasesQuery is the scheme's database listing query.
+	DatabasesQuery string `json:"databases_query,omitempty"`
+	// SchemasQuery is the scheme's schema listing query.
+	SchemasQuery string `json:"schemas_query,omitempty"`
+	// CurrentDatabaseQuery is the scheme's current database query.
+	CurrentDatabaseQuery string `json:"current_database_query,omitempty"`
+	// Examples are the scheme's example URLs.
+	Examples []string `json:"examples,omitempty"`
+	// Tags are the scheme's grouping tags.
+	Tags []string `json:"tags,omitempty"`
+	// TransportParam is the scheme's transport parameter mapping.
+	TransportParam *TransportParam `json:"transport_param,omitempty"`
+}
+
+// LoadSchemes reads a JSON or YAML manifest (an array of [ManifestScheme])
+// from the reader and registers the described schemes. Manifests written by
+// [DumpSchemes] can be loaded.
+//
+// Only the subset of YAML needed for manifests is supported: a sequence of
+// mappings with plain or quoted scalars, flow sequences ("[a, b]"), flow
+// mappings ("{a: b}"), and a single level of nested block mappings (ie,
+// "transport_param"), using the same field names as JSON manifests:
+//
+//	# schemes.yaml
+//	- driver: xmzdb
+//	  aliases: [xmzd]
+//	  generator: "postgres://localhost:6875/"
+//	  default_port: "6875"
+//
+// All schemes in the manifest are validated prior to any being registered.
+func LoadSchemes(r io.Reader) error {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return &ManifestError{Err: err}
+	}
+	if z := bytes.TrimSpace(buf); len(z) != 0 && z[0] != '[' {
+		if buf, err = manifestYAML(buf); err != nil {
+			return &ManifestError{Err: err}
+		}
+	}
+	var manifest []ManifestScheme
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&manifest); err != nil {
+		return &ManifestError{Err: err}
+	}
+	schemes := make([]Scheme, len(manifest))
+	seen := make(map[string]bool)
+	for i, m := range manifest {
+		scheme, err := m.scheme()
+		if err != nil {
+			return &ManifestError{Driver: m.Driver, Err: err}
+		}
+		for _, name := range schemeNames(scheme) {
+			if _, ok := lookupScheme(name); ok || seen[name] {
+				return &ManifestError{Driver: m.Driver, Err: fmt.Errorf("scheme %s already registered", name)}
+			}
+			seen[name] = true
+		}
+		schemes[i] = scheme
+	}
+	for _, scheme := range schemes {
+		Register(scheme)
+	}
+	return nil
+}
+
+// scheme builds the scheme for the manifest entry.
+func (m ManifestScheme) scheme() (Scheme, error) {
+	if m.Driver == "" {
+		return Scheme{}, ErrInvalidDatabaseScheme
+	}
+	scheme := Scheme{
+		Driver:               m.Driver,
+		Opaque:               m.Opaque,
+		Aliases:              m.Aliases,
+		Override:             m.Override,
+		DefaultPort:          m.DefaultPort,
+		HTTPPort:             m.HTTPPort,
+		DefaultUser:          m.DefaultUser,
+		Package:              m.Package,
+		RequiresCGO:          m.RequiresCGO,
+		PureGoAlternative:    m.PureGoAlternative,
+		PingQuery:            m.PingQuery,
+		VersionQuery:         m.VersionQuery,
+		DatabasesQuery:       m.DatabasesQuery,
+		SchemasQuery:         m.SchemasQuery,
+		CurrentDatabaseQuery: m.CurrentDatabaseQuery,
+		Examples:             m.Examples,
+		Tags:                 m.Tags,
+		TransportParam:       m.TransportParam,
+		manifestGenerator:    m.Generator,
+	}
+	// generator
+	switch {
+	case m.Generator == "":
+		return Scheme{}, fmt.Errorf("missing generator")
+	case strings.Contains(m.Generator, "://"):
+		gen, err := genFromURL(m.Generator, QueryMergeReplace)
+		if err != nil {
+			return Scheme{}, err
+		}
+		scheme.Generator = gen
+	case strings.HasPrefix(m.Generator, "GenScheme:"):
+		name := strings.TrimPrefix(m.Generator, "GenScheme:")
+		if name == "" {
+			return Scheme{}, fmt.Errorf("missing generator scheme")
+		}
+		scheme.Generator = GenScheme(name)
+	case builtinGenerators[m.Generator] != nil:
+		scheme.Generator = builtinGenerators[m.Generator]
+	default:
+		z, ok := lookupScheme(m.Generator)
+		if !ok {
+			return Scheme{}, fmt.Errorf("unknown generator %q", m.Generator)
+		}
+		scheme.Generator, scheme.GeneratorContext = z.Generator, z.GeneratorContext
+		// use the scheme's generator, so that the dumped manifest does not
+		// depend on the scheme being registered
+		if z.manifestGenerator != "" {
+			scheme.manifestGenerator = z.manifestGenerator
+		}
+	}
+	// transports
+	for _, name := range m.Transports {
+		var ok bool
+		for _, t := range transportNames {
+			if strings.EqualFold(name, t.name) {
+				scheme.Transport, ok = scheme.Transport|t.t, true
+			}
+		}
+		if !ok {
+			return Scheme{}, fmt.Errorf("unknown transport %q", name)
+		}
+	}
+	if scheme.Opaque && scheme.Transport&TransportUnix != 0 {
+		return Scheme{}, fmt.Errorf("scheme must support only Opaque or Unix protocols, not both")
+	}
+	// placeholder
+	if m.Placeholder != "" {
+		p, ok := parsePlaceholder(m.Placeholder)
+		if !ok {
+			return Scheme{}, fmt.Errorf("unknown placeholder %q", m.Placeholder)
+		}
+		scheme.Placeholder = p
+	}
+	// identifier quote
+	if m.IdentQuote != "" {
+		q, ok := parseIdentQuote(m.IdentQuote)
+		if !ok {
+			return Scheme{}, fmt.Errorf("unknown identifier quote %q", m.IdentQuote)
+		}
+		scheme.IdentQuote = q
+	}
+	if m.TransportParam != nil && m.TransportParam.Param == "" {
+		return Scheme{}, fmt.Errorf("missing transport param")
+	}
+	return scheme, nil
+}
+
+// DumpSchemes writes a JSON manifest of the registered schemes to the writer,
+// sorted by driver name, that can be loaded with [LoadSchemes].
+//
+// Generator is written for the built-in schemes and schemes loaded from a
+// manifest. Schemes registered with a custom generator using [Register] are
+// written without a Generator, and must be registered again prior to loading
+// the manifest.
+func DumpSchemes(w io.Writer) error {
+	var manifest []ManifestScheme
+	for _, scheme := range registered() {
+		var aliases []string
+		for _, alias := range scheme.Aliases {
+			if alias != scheme.Driver {
+				aliases = append(aliases, alias)
+			}
+		}
+		m := ManifestScheme{
+			Driver:               scheme.Driver,
+			Aliases:              aliases,
+			Override:             scheme.Override,
+			Generator:            scheme.manifestGenerator,
+			Transports:           scheme.Transports(),
+			Opaque:               scheme.Opaque,
+			DefaultPort:          scheme.DefaultPort,
+			HTTPPort:             scheme.HTTPPort,
+			DefaultUser:          scheme.DefaultUser,
+			Package:              scheme.Package,
+			RequiresCGO:          scheme.RequiresCGO,
+			PureGoAlternative:    scheme.PureGoAlternative,
+			Placeholder:          scheme.Placeholder.String(),
+			IdentQuote:           scheme.IdentQuote.String(),
+			PingQuery:            scheme.PingQuery,
+			VersionQuery:         scheme.VersionQuery,
+			DatabasesQuery:       scheme.DatabasesQuery,
+			SchemasQuery:         scheme.SchemasQuery,
+			CurrentDatabaseQuery: scheme.CurrentDatabaseQuery,
+			Examples:             scheme.Examples,
+			Tags:                 scheme.Tags,
+		}
+		if scheme.TransportParam != nil {
+			p := scheme.TransportParam.copy()
+			m.TransportParam = &p
+		}
+		manifest = append(manifest, m)
+	}
+	sort.Slice(manifest, func(i, j int) bool {
+		return manifest[i].Driver < manifest[j].Driver
+	})
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(manifest)
+}
+
+// ManifestError is a scheme manifest error.
+type ManifestError struct {
+	// Driver is the driver name of the manifest scheme with the error, if
+	// any.
+	Driver string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *ManifestError) Error() string {
+	if err.Driver == "" {
+		return fmt.Sprintf("scheme manifest: %v", err.Err)
+	}
+	return fmt.Sprintf("scheme manifest %s: %v", err.Driver, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *ManifestError) Unwrap() error {
+	return err.Err
+}
+
+// manifestYAML converts a YAML manifest to the equivalent JSON manifest. See
+// [LoadSchemes].
+func manifestYAML(buf []byte) ([]byte, error) {
+	manifest := make([]map[string]any, 0)
+	var cur, nested map[string]any
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	for i := 1; s.Scan(); i++ {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if z := strings.TrimSpace(line); z == "" || z == "---" || strings.HasPrefix(z, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "- ") {
+			cur, nested = make(map[string]any), nil
+			manifest = append(manifest, cur)
+			line = "  " + line[2:]
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("line %d: expected scheme", i)
+		}
+		k, v, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("line %d: invalid line", i)
+		}
+		var m map[string]any
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 2:
+			m, nested = cur, nil
+		case indent == 4 && nested != nil:
+			m = nested
+		default:
+			return nil, fmt.Errorf("line %d: invalid indent", i)
+		}
+		if _, ok := m[k]; ok {
+			return nil, fmt.Errorf("line %d: duplicate field %q", i, k)
+		}
+		v = yamlComment(strings.TrimSpace(v))
+		if v == "" && indent == 2 {
+			nested = make(map[string]any)
+			m[k] = nested
+			continue
+		}
+		val, err := yamlValue(k, v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i, err)
+		}
+		m[k] = val
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(manifest)
+}
+
+// yamlValue parses the YAML value of the field k, a flow sequence, a flow
+// mapping, or a scalar.
+func yamlValue(k, v string) (any, error) {
+	switch {
+	case strings.HasPrefix(v, "["):
+		if !strings.HasSuffix(v, "]") {
+			return nil, fmt.Errorf("invalid sequence %q", v)
+		}
+		seq := make([]any, 0)
+		for _, z := range yamlSplit(v[1 : len(v)-1]) {
+			s, err := yamlScalar(z)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, s)
+		}
+		return seq, nil
+	case strings.HasPrefix(v, "{"):
+		if !strings.HasSuffix(v, "}") {
+			return nil, fmt.Errorf("invalid mapping %q", v)
+		}
+		m := make(map[string]any)
+		for _, z := range yamlSplit(v[1 : len(v)-1]) {
+			key, val, ok := strings.Cut(z, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid mapping entry %q", z)
+			}
+			ks, err := yamlScalar(strings.TrimSpace(key))
+			if err != nil {
+				return nil, err
+			}
+			if m[ks], err = yamlScalar(strings.TrimSpace(val)); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	s, err := yamlScalar(v)
+	if err != nil {
+		return nil, err
+	}
+	switch k {
+	case "opaque", "requires_cgo", "query":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", k, s)
+		}
+		return b, nil
+	}
+	return s, nil
+}
+
+// yamlScalar parses a plain, double quoted, or single quoted YAML scalar.
+func yamlScalar(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		return strconv.Unquote(v)
+	case strings.HasPrefix(v, "'"):
+		if len(v) < 2 || !strings.HasSuffix(v, "'") {
+			return "", fmt.Errorf("invalid scalar %s", v)
+		}
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'"), nil
+	}
+	return v, nil
+}
+
+// yamlSplit splits the entries of a YAML flow sequence or mapping on commas
+// outside of quoted scalars, skipping empty entries.
+func yamlSplit(s string) []string {
+	var v []string
+	var quote byte
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		switch {
+		case i == len(s), quote == 0 && s[i] == ',':
+			if z := strings.TrimSpace(s[start:i]); z != "" {
+				v = append(v, z)
+			}
+			start = i + 1
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		case quote == 0 && (s[i] == '"' || s[i] == '\''):
+			quote = s[i]
+		}
+	}
+	return v
+}
+
+// yamlComment strips a trailing comment (preceded by whitespace, as URLs may
+// contain '#') outside of quoted scalars from the YAML value.
+func yamlComment(v string) string {
+	var quote byte
+	for i := 0; i < len(v); i++ {
+		switch {
+		case quote == '"' && v[i] == '\\':
+			i++
+		case quote != 0 && v[i] == quote:
+			quote = 0
+		case quote == 0 && (v[i] == '"' || v[i] == '\''):
+			quote = v[i]
+		case quote == 0 && v[i] == '#' && (i == 0 || v[i-1] == ' ' || v[i-1] == '\t'):
+			return strings.TrimSpace(v[:i])
+		}
+	}
+	return v
+}
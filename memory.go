@@ -0,0 +1,52 @@
+package dburl
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// parseMemory parses a memory:// or tmpfile:// URL (ie, "memory://sqlite",
+// "tmpfile://duckdb?dir=/tmp"), re-parsing the URL as the host's file based
+// scheme with an in-memory (":memory:") or newly created temporary file path.
+//
+// For tmpfile:// URLs, the temporary file is created in the "dir" query
+// parameter, or the default directory for temporary files, and is not removed.
+func parseMemory(u *URL, scheme *Scheme, o *options) (*URL, error) {
+	if u.Transport != "tcp" {
+		return nil, ErrInvalidTransportProtocol
+	}
+	target, ok := lookupScheme(u.Hostname())
+	if !ok || !target.Opaque || target.Driver == "file" || u.Port() != "" {
+		return nil, ErrUnknownDatabaseScheme
+	}
+	name, rawQuery := ":memory:", u.RawQuery
+	if scheme.Driver == "tmpfile" {
+		q := u.Query()
+		f, err := os.CreateTemp(q.Get("dir"), "dburl-*"+tempExt(target.Driver))
+		if err != nil {
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		name, rawQuery = f.Name(), stripRawQuery(rawQuery, "dir")
+		if !filepath.IsAbs(name) {
+			if name, err = filepath.Abs(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return parse((&url.URL{Scheme: u.Hostname(), Opaque: name, RawQuery: rawQuery}).String(), o)
+}
+
+// tempExt returns the temporary file extension for the driver.
+func tempExt(driver string) string {
+	switch driver {
+	case "sqlite3", "moderncsqlite":
+		return ".db"
+	case "duckdb":
+		return ".duckdb"
+	}
+	return ""
+}
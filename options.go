@@ -0,0 +1,309 @@
+package dburl
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Option is a [ParseWithOptions] option.
+type Option func(*options)
+
+// options are the options used when parsing a [URL].
+type options struct {
+	// ctx is the parse context.
+	ctx context.Context
+	// ignoreQueryPrefixes are additional query prefixes to ignore when
+	// generating ODBC style DSNs.
+	ignoreQueryPrefixes []string
+	// hostRewrites are host rewrites.
+	hostRewrites map[string]string
+	// defaultScheme is the default scheme.
+	defaultScheme string
+	// guessScheme toggles guessing the scheme from the port.
+	guessScheme bool
+	// classify toggles skipping DSN generation.
+	classify bool
+	// strict toggles returning an error for ambiguous URLs.
+	strict bool
+	// noDefaults toggles suppressing implicit hosts, ports, and users.
+	noDefaults bool
+	// queryOrder toggles generating query parameters in the URL's order.
+	queryOrder bool
+	// baseDir is the directory relative file paths are resolved against.
+	baseDir string
+	// stat is the stat func.
+	stat func(string) (fs.FileInfo, error)
+	// openFile is the open file func.
+	openFile func(string) (fs.File, error)
+	// transport is the transport used for URLs without a transport.
+	transport string
+	// validateCerts toggles validating client certificates when opened.
+	validateCerts bool
+	// shorthand is the shorthand func.
+	shorthand ShorthandFunc
+	// requireSecure toggles refusing plaintext connections when opened.
+	requireSecure bool
+	// fallbackDrivers toggles opening with pure Go alternative drivers.
+	fallbackDrivers bool
+}
+
+// newOptions creates the options.
+func newOptions(ctx context.Context, opts ...Option) *options {
+	o := &options{
+		ctx: ctx,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithIgnoreQueryPrefixes is a parse option to ignore query parameters with
+// the specified (lower case) prefixes when generating ODBC style DSNs (adodb,
+// cosmos, godynamo, odbc, oleodbc).
+//
+// The prefixes are used in addition to the scheme's IgnoreQueryPrefixes and
+// the package level [OdbcIgnoreQueryPrefixes].
+func WithIgnoreQueryPrefixes(prefixes ...string) Option {
+	return func(o *options) {
+		o.ignoreQueryPrefixes = append(o.ignoreQueryPrefixes, prefixes...)
+	}
+}
+
+// WithDefaultScheme is a parse option to set the scheme used for URL strings
+// without a scheme that look like a "[user@]host[:port][/dbname][?query]"
+// string, rather than a file path (ie, "localhost:5432/mydb").
+func WithDefaultScheme(scheme string) Option {
+	return func(o *options) {
+		o.defaultScheme = scheme
+	}
+}
+
+// WithGuessScheme is a parse option to guess the scheme for URL strings
+// without a scheme that look like a "[user@]host:port[/dbname][?query]"
+// string using [GuessScheme], falling back to the scheme set by
+// [WithDefaultScheme] when the port is not recognized.
+func WithGuessScheme() Option {
+	return func(o *options) {
+		o.guessScheme = true
+	}
+}
+
+// WithStrict is a parse option to return an [AmbiguityError] for URLs
+// without a "//" whose opaque component could be interpreted as either a host
+// or a relative path (ie, "pg:host:5432"), instead of re-parsing the URL as
+// "scheme://<opaque>". When used with [FromMapWithOptions], unrecognized
+// component keys return an [UnknownKeyError].
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithoutDefaults is a parse option to suppress the implicit host (ie,
+// "localhost"), port, and user (see the Scheme's DefaultUser) that would
+// otherwise be added to generated DSNs when not specified by the URL, leaving
+// defaulting (or service discovery) to the driver.
+func WithoutDefaults() Option {
+	return func(o *options) {
+		o.noDefaults = true
+	}
+}
+
+// WithQueryOrder is a parse option to generate order-sensitive DSNs (ADODB,
+// ODBC, and similar "key=value;" style connection strings) with query
+// parameters in the order they were specified in the URL, instead of sorted
+// by key. See [URL.OrderedQuery].
+func WithQueryOrder() Option {
+	return func(o *options) {
+		o.queryOrder = true
+	}
+}
+
+// WithBaseDir is a parse option to resolve relative file paths (ie,
+// "sq:./data/app.db", "fake.duckdb") against the directory, instead of the
+// process' working directory.
+func WithBaseDir(dir string) Option {
+	return func(o *options) {
+		o.baseDir = dir
+	}
+}
+
+// WithStat is a parse option to use the stat func when resolving Unix domain
+// sockets, socket directories, and file paths, instead of the package level
+// [Stat] (and the stat cache enabled by [SetStatCache]).
+func WithStat(stat func(string) (fs.FileInfo, error)) Option {
+	return func(o *options) {
+		o.stat = stat
+	}
+}
+
+// WithOpenFile is a parse option to use the open file func when reading file
+// headers, instead of the package level [OpenFile].
+func WithOpenFile(openFile func(string) (fs.File, error)) Option {
+	return func(o *options) {
+		o.openFile = openFile
+	}
+}
+
+// WithoutFileProbing is a parse option to disable probing the filesystem
+// when parsing, as if no paths exist. Unix domain sockets and socket
+// directories are not resolved, and the scheme of file paths is determined
+// by the file extension only.
+func WithoutFileProbing() Option {
+	return func(o *options) {
+		o.stat = func(name string) (fs.FileInfo, error) {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+		}
+		o.openFile = func(name string) (fs.File, error) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+	}
+}
+
+// WithTransport is a parse option to set the transport protocol (ie, "unix")
+// for URLs whose scheme does not specify a transport (ie, "pg://" but not
+// "pg+unix://"). The transport must be allowed by the scheme.
+func WithTransport(transport string) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithClientCertValidation is a parse option to load and validate the URL's
+// client certificate and key files (see [ValidateClientCert]) when the URL is
+// opened with [OpenURL], returning a [CertError] prior to connecting when a
+// file is missing or the certificate and key are invalid.
+func WithClientCertValidation() Option {
+	return func(o *options) {
+		o.validateCerts = true
+	}
+}
+
+// RequireSecure is a parse option to refuse opening the URL with [OpenURL]
+// when a password would be sent over a plaintext connection (ie, a
+// [ProblemPlaintextCredentials] diagnostic), returning
+// [ErrInsecureConnection]. For compliance sensitive deployments.
+func RequireSecure() Option {
+	return func(o *options) {
+		o.requireSecure = true
+	}
+}
+
+// AllowFallbackDrivers is a parse option to open the URL with [OpenURL] using
+// the scheme's pure Go alternative (see [Scheme.PureGoAlternative]), when the
+// URL's Go SQL driver requires CGO and is not available (ie, opening
+// "sqlite3" URLs with "moderncsqlite" in a pure Go build), instead of
+// returning a [DriverError].
+func AllowFallbackDrivers() Option {
+	return func(o *options) {
+		o.fallbackDrivers = true
+	}
+}
+
+// WithHostRewrites is a parse option to rewrite a URL's host name at parse
+// time, allowing short names to stand in for the real host (ie, rewriting
+// "pg://prod-orders/" to "pg://orders.db.example.com/").
+//
+// A rewritten host may include a port, which is used unless the URL specifies
+// a port. Rewrites take precedence over the package level aliases set by
+// [SetHostAliases].
+func WithHostRewrites(rewrites map[string]string) Option {
+	return func(o *options) {
+		if o.hostRewrites == nil {
+			o.hostRewrites = make(map[string]string, len(rewrites))
+		}
+		for k, v := range rewrites {
+			o.hostRewrites[k] = v
+		}
+	}
+}
+
+// hostAliases are the package level host aliases.
+var hostAliases struct {
+	sync.RWMutex
+	m map[string]string
+}
+
+// SetHostAliases sets the package level host aliases used to rewrite a URL's
+// host name at parse time. See [WithHostRewrites].
+func SetHostAliases(aliases map[string]string) {
+	m := make(map[string]string, len(aliases))
+	for k, v := range aliases {
+		m[k] = v
+	}
+	hostAliases.Lock()
+	defer hostAliases.Unlock()
+	hostAliases.m = m
+}
+
+// resolvePath returns the path resolved against the base directory, when the
+// path is relative.
+func (o *options) resolvePath(name string) string {
+	if o.baseDir == "" || name == "" || strings.HasPrefix(name, ":") || filepath.IsAbs(name) || isDrivePath(opaquePath(name)) {
+		return name
+	}
+	return filepath.Join(o.baseDir, name)
+}
+
+// statFile stats the named file using the stat func, falling back to [Stat]
+// (using the stat cache, when enabled).
+func (o *options) statFile(name string) (fs.FileInfo, error) {
+	if o != nil && o.stat != nil {
+		return o.stat(name)
+	}
+	return cachedStat(name)
+}
+
+// open opens the named file using the open file func, falling back to
+// [OpenFile].
+func (o *options) open(name string) (fs.File, error) {
+	if o != nil && o.openFile != nil {
+		return o.openFile(name)
+	}
+	return OpenFile(name)
+}
+
+// schemeType returns the scheme type for the path resolved against the base
+// directory. When the resolved path does not exist, the scheme type is
+// determined by the file extension only, as the path is not relative to the
+// working directory.
+func (o *options) schemeType(name string) (string, error) {
+	s, f := o.resolvePath(name), func(s string) (string, error) {
+		return probeType(s, o)
+	}
+	if s != name {
+		if _, err := o.statFile(s); err != nil {
+			f = extType
+		}
+	}
+	typ, err := f(s)
+	if err == nil {
+		incStat(StatFile, typ)
+	}
+	return typ, err
+}
+
+// rewriteHost returns the rewritten host for the host and port.
+func (o *options) rewriteHost(host, port string) (string, bool) {
+	s, ok := o.hostRewrites[host]
+	if !ok {
+		hostAliases.RLock()
+		s, ok = hostAliases.m[host]
+		hostAliases.RUnlock()
+	}
+	if !ok {
+		return "", false
+	}
+	if port != "" {
+		if h, _, err := net.SplitHostPort(s); err == nil {
+			s = h
+		}
+		return net.JoinHostPort(s, port), true
+	}
+	return s, true
+}
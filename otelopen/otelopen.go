@@ -0,0 +1,100 @@
+// Package otelopen provides an opt-in open hook for instrumenting databases
+// opened with [dburl.Open] (ie, with OpenTelemetry tracing and metrics).
+//
+// As instrumentation requires packages outside of Go's standard library (ie,
+// github.com/XSAM/otelsql), the driver of each opened database is wrapped by
+// the registered [WrapFunc]:
+//
+//	import "github.com/xo/dburl/otelopen"
+//
+//	otelopen.Register(func(d driver.Driver, u *dburl.URL) driver.Driver {
+//		return otelsql.WrapDriver(d, otelsql.WithAttributes(semconv.DBSystemKey.String(u.Driver)))
+//	})
+//
+// Databases are opened unchanged until a func is registered.
+package otelopen
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+
+	"github.com/xo/dburl"
+)
+
+func init() {
+	dburl.RegisterOpenHook(Hook)
+}
+
+// WrapFunc is a func that wraps the driver of a database opened for the URL.
+type WrapFunc func(driver.Driver, *dburl.URL) driver.Driver
+
+// wrapFunc is the registered wrap func.
+var wrapFunc struct {
+	sync.RWMutex
+	f WrapFunc
+}
+
+// Register registers the func used to wrap the driver of opened databases.
+func Register(f WrapFunc) {
+	if f == nil {
+		panic("must specify a non-nil WrapFunc")
+	}
+	wrapFunc.Lock()
+	defer wrapFunc.Unlock()
+	wrapFunc.f = f
+}
+
+// Hook is the [dburl.OpenHook] registered by the package, reopening the
+// database with its driver wrapped by the registered func.
+func Hook(next dburl.OpenFunc) dburl.OpenFunc {
+	return func(u *dburl.URL) (*sql.DB, error) {
+		db, err := next(u)
+		if err != nil {
+			return nil, err
+		}
+		wrapFunc.RLock()
+		f := wrapFunc.f
+		wrapFunc.RUnlock()
+		if f == nil {
+			return db, nil
+		}
+		return Wrap(db, u, f)
+	}
+}
+
+// Wrap reopens the database using its driver wrapped by f, connecting with
+// the URL's DSN. The database is closed, and should not have been used prior
+// to being wrapped.
+func Wrap(db *sql.DB, u *dburl.URL, f WrapFunc) (*sql.DB, error) {
+	d := f(db.Driver(), u)
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+	if dc, ok := d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(u.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(c), nil
+	}
+	return sql.OpenDB(dsnConnector{d, u.DSN}), nil
+}
+
+// dsnConnector is a connector for drivers not implementing
+// [driver.DriverContext].
+type dsnConnector struct {
+	d   driver.Driver
+	dsn string
+}
+
+// Connect satisfies the [driver.Connector] interface.
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.d.Open(c.dsn)
+}
+
+// Driver satisfies the [driver.Connector] interface.
+func (c dsnConnector) Driver() driver.Driver {
+	return c.d
+}
@@ -0,0 +1,82 @@
+package dburl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DriverPackage returns the import path of the Go SQL driver package for a
+// registered scheme name or alias (ie, "github.com/lib/pq" for "pg"). For wire
+// compatible schemes without a Package, the Package of the Override scheme is
+// returned. Returns an empty string when the package is not known.
+func DriverPackage(name string) string {
+	scheme, ok := lookupScheme(name)
+	if !ok {
+		return ""
+	}
+	if scheme.Package == "" && scheme.Override != "" {
+		if z, ok := lookupScheme(scheme.Override); ok {
+			return z.Package
+		}
+	}
+	return scheme.Package
+}
+
+// DriverPackage returns the import path of the Go SQL driver package needed
+// to open the URL. See [DriverPackage].
+func (u *URL) DriverPackage() string {
+	if u.GoDriver != "" {
+		return DriverPackage(u.GoDriver)
+	}
+	return DriverPackage(u.UnaliasedDriver)
+}
+
+// DriverPackages parses the URLs, returning the sorted, unique import paths of
+// the Go SQL driver packages needed to open them. A [PackageError] is returned
+// when the package for a URL's driver is not known.
+func DriverPackages(urlstrs ...string) ([]string, error) {
+	seen := make(map[string]bool)
+	var pkgs []string
+	for _, urlstr := range urlstrs {
+		u, err := Parse(urlstr)
+		if err != nil {
+			return nil, err
+		}
+		pkg := u.DriverPackage()
+		switch {
+		case pkg == "":
+			return nil, &PackageError{Driver: u.UnaliasedDriver}
+		case !seen[pkg]:
+			pkgs, seen[pkg] = append(pkgs, pkg), true
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
+
+// ImportBlock returns a Go import block of blank imports for the packages, as
+// needed to register the Go SQL drivers:
+//
+//	import (
+//		_ "github.com/lib/pq"
+//	)
+func ImportBlock(pkgs []string) string {
+	var sb strings.Builder
+	sb.WriteString("import (\n")
+	for _, pkg := range pkgs {
+		fmt.Fprintf(&sb, "\t_ %q\n", pkg)
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
+
+// GoGetCommand returns the go get command for the packages (ie, "go get
+// github.com/lib/pq@latest").
+func GoGetCommand(pkgs []string) string {
+	v := []string{"go", "get"}
+	for _, pkg := range pkgs {
+		v = append(v, pkg+"@latest")
+	}
+	return strings.Join(v, " ")
+}
@@ -0,0 +1,482 @@
+package dburl
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// translator translates the portable query parameters in q into a driver's
+// native query parameters in out, removing the portable parameters it
+// handles from q. A nil translator indicates the driver does not support the
+// parameters.
+type translator func(q, out url.Values) error
+
+// portableParam is a group of portable query parameters and their
+// translators, keyed by driver or database family.
+type portableParam struct {
+	keys    []string
+	drivers map[string]translator
+	// validate validates the portable parameter values.
+	validate func(string) bool
+	// passthrough toggles passing the parameters through unchanged for
+	// drivers without a translator, or when left in place by the translator
+	// (ie, a parameter the driver natively accepts). Only drivers with a nil
+	// translator do not support the parameters.
+	passthrough bool
+}
+
+// portableParams are the portable query parameters.
+var portableParams = []portableParam{
+	// kerberos
+	{
+		keys: []string{"krb5_realm", "krb5_keytab", "krb5_ccache", "spn"},
+		drivers: map[string]translator{
+			"sqlserver": renameParams(map[string]string{"authenticator": "krb5"},
+				"krb5_realm", "krb5-realm",
+				"krb5_keytab", "krb5-keytabfile",
+				"krb5_ccache", "krb5-credcachefile",
+				"spn", "ServerSPN",
+			),
+			"postgres": krb5Postgres(nil),
+			"pgx":      krb5Postgres(map[string]string{"gssencmode": "prefer"}),
+			"hive": renameParams(map[string]string{"auth": "KERBEROS"},
+				"spn", "service",
+			),
+			"impala": renameParams(map[string]string{"auth": "KERBEROS"},
+				"spn", "service",
+			),
+			"trino": renameParams(map[string]string{"KerberosEnabled": "true"},
+				"krb5_realm", "KerberosRealm",
+				"krb5_keytab", "KerberosKeytabPath",
+				"krb5_ccache", "KerberosCredentialCachePath",
+				"spn", "KerberosRemoteServiceName",
+			),
+		},
+	},
+	// charset
+	{
+		keys: []string{"charset"},
+		drivers: map[string]translator{
+			"mysql": func(q, out url.Values) error {
+				// "utf8" is a distinct (legacy) mysql charset, and a list of
+				// charsets may be specified
+				v := strings.Split(strings.ToLower(q.Get("charset")), ",")
+				for i := range v {
+					if v[i] == "utf-8" {
+						v[i] = "utf8mb4"
+					}
+				}
+				q.Del("charset")
+				out.Set("charset", strings.Join(v, ","))
+				return nil
+			},
+			"mymysql":   nil,
+			"sqlserver": nil,
+			"tds":       passthroughParams,
+			"postgres": func(q, out url.Values) error {
+				v := strings.ToUpper(q.Get("charset"))
+				if isUTF8(v) {
+					v = "UTF8"
+				}
+				q.Del("charset")
+				out.Set("client_encoding", v)
+				return nil
+			},
+			"oracle": func(q, out url.Values) error {
+				// only NLS_LANG style values (ie, "AMERICAN_AMERICA.AL32UTF8")
+				// are translated, as the character set is negotiated with
+				// the server, and other values are passed through
+				v, _, _ := strings.Cut(q.Get("charset"), ".")
+				language, territory, ok := strings.Cut(v, "_")
+				if !ok || language == "" || territory == "" {
+					return nil
+				}
+				q.Del("charset")
+				out.Set("language", language)
+				out.Set("territory", territory)
+				return nil
+			},
+		},
+		validate: func(v string) bool {
+			return charsetRE.MatchString(v)
+		},
+		passthrough: true,
+	},
+	// timezone
+	{
+		keys: []string{"timezone"},
+		drivers: map[string]translator{
+			"mysql": func(q, out url.Values) error {
+				// loc is only used when parsing time values
+				out.Set("loc", q.Get("timezone"))
+				out.Set("parseTime", "true")
+				q.Del("timezone")
+				return nil
+			},
+			"mymysql":    nil,
+			"sqlserver":  nil,
+			"tds":        passthroughParams,
+			"postgres":   renameParams(nil, "timezone", "TimeZone"),
+			"clickhouse": renameParams(nil, "timezone", "session_timezone"),
+			"snowflake":  renameParams(nil, "timezone", "TIMEZONE"),
+		},
+		validate: func(v string) bool {
+			return timezoneRE.MatchString(v)
+		},
+		passthrough: true,
+	},
+	// compress
+	{
+		keys: []string{"compress"},
+		drivers: map[string]translator{
+			"mysql":      boolParam("compress", "compress", "true", "false"),
+			"mymysql":    nil,
+			"clickhouse": boolParam("compress", "compress", "lz4", "false"),
+			// spooling protocol encoding
+			"trino": boolParam("compress", "encoding", "json+zstd", "json"),
+			// results are always compressed, and cannot be disabled
+			"snowflake": boolParam("compress", "", "true", ""),
+		},
+	},
+	// tls
+	{
+		keys: tlsKeys,
+		drivers: map[string]translator{
+			"mysql": func(q, out url.Values) error {
+				if err := tlsPolicy(q); err != nil {
+					return err
+				}
+				// registered when opened, see registerTLSConfig, and
+				// conflicts with a native tls value (ie, "tls=true")
+				name := tlsConfigName(q)
+				if v := q.Get("tls"); q.Has("tls") && v != name {
+					return &ParamError{Param: "tls", Value: v, Err: ErrInvalidParameter}
+				}
+				out.Set("tls", name)
+				for _, k := range tlsKeys {
+					q.Del(k)
+				}
+				return nil
+			},
+			"mymysql": nil,
+			"postgres": func(q, out url.Values) error {
+				skip, err := tlsSkipVerify(q)
+				if err != nil {
+					return err
+				}
+				switch {
+				case skip:
+					out.Set("sslmode", "require")
+				case q.Has("tls_ca"):
+					out.Set("sslmode", "verify-full")
+				}
+				if err := tlsPolicy(q); err != nil {
+					return err
+				}
+				if v := q.Get("tls_min_version"); v != "" {
+					out.Set("ssl_min_protocol_version", "TLSv"+v)
+				}
+				// the server name and cipher suites can only be set
+				// programmatically, and remain in the URL for use with
+				// TLSConfig
+				for _, k := range []string{"tls_insecure_skip_verify", "tls_min_version", "tls_server_name", "tls_cipher_suites"} {
+					q.Del(k)
+				}
+				return renameParams(nil,
+					"tls_ca", "sslrootcert",
+					"tls_cert", "sslcert",
+					"tls_key", "sslkey",
+				)(q, out)
+			},
+			"sqlserver": func(q, out url.Values) error {
+				skip, err := tlsSkipVerify(q)
+				if err != nil {
+					return err
+				}
+				if _, err := tlsMinVersion(q); err != nil {
+					return err
+				}
+				out.Set("encrypt", "true")
+				if skip {
+					out.Set("TrustServerCertificate", "true")
+				}
+				q.Del("tls_insecure_skip_verify")
+				return renameParams(nil,
+					"tls_ca", "certificate",
+					"tls_cert", "clientcertpath",
+					"tls_key", "clientkeypath",
+					"tls_server_name", "hostNameInCertificate",
+					"tls_min_version", "tlsmin",
+				)(q, out)
+			},
+			"cql": renameParams(nil,
+				"tls_ca", "caPath",
+				"tls_cert", "certPath",
+				"tls_key", "keyPath",
+			),
+			// certificates are only accepted programmatically (see
+			// TLSConfig)
+			"clickhouse": func(q, out url.Values) error {
+				skip, err := tlsSkipVerify(q)
+				if err != nil {
+					return err
+				}
+				out.Set("secure", "true")
+				if skip {
+					out.Set("skip_verify", "true")
+				}
+				return tlsProgrammatic(q, out)
+			},
+			"hive": tlsProgrammatic,
+		},
+	},
+}
+
+// tlsSkipVerify returns the value of the portable "tls_insecure_skip_verify"
+// query parameter.
+func tlsSkipVerify(q url.Values) (bool, error) {
+	s := q.Get("tls_insecure_skip_verify")
+	if s == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, &ParamError{Param: "tls_insecure_skip_verify", Value: s, Err: ErrInvalidParameter}
+	}
+	return b, nil
+}
+
+// charsetRE matches valid charset names.
+var charsetRE = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*(,[A-Za-z][A-Za-z0-9_.-]*)*$`)
+
+// timezoneRE matches valid time zone names (ie, "UTC", "Europe/Berlin",
+// "Etc/GMT+1").
+var timezoneRE = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+-]*(/[A-Za-z0-9_+-]+)*$`)
+
+// isUTF8 returns true when the charset is a UTF-8 charset.
+func isUTF8(charset string) bool {
+	switch strings.ToLower(charset) {
+	case "utf8", "utf-8", "utf8mb4":
+		return true
+	}
+	return false
+}
+
+// openParams are the portable query parameters used when opening a URL,
+// that are removed prior to DSN generation.
+var openParams = append([]string{credentialsDirParam, srvTargetsParam, ipParam, httpPortParam}, poolParams...)
+
+// translateParams translates the URL's portable query parameters into the
+// driver's native query parameters, prior to DSN generation.
+func translateParams(u *URL, scheme *Scheme) error {
+	q := u.Query()
+	if v := q.Get(ipParam); q.Has(ipParam) && !contains([]string{"4", "6", "any"}, v) {
+		return &ParamError{Driver: scheme.Driver, Param: ipParam, Value: v, Err: ErrInvalidParameter}
+	}
+	if v := q.Get(httpPortParam); q.Has(httpPortParam) && !isPort(v) {
+		return &ParamError{Driver: scheme.Driver, Param: httpPortParam, Value: v, Err: ErrInvalidParameter}
+	}
+	if err := validatePool(q, scheme.Driver); err != nil {
+		return err
+	}
+	changed, err := normalizeCertParams(q, scheme)
+	if err != nil {
+		return err
+	}
+	for _, k := range openParams {
+		if q.Has(k) {
+			q.Del(k)
+			changed = true
+		}
+	}
+	for _, p := range portableParams {
+		if !hasAnyKey(q, p.keys) {
+			continue
+		}
+		f, ok := p.drivers[scheme.Driver]
+		if !ok {
+			f, ok = p.drivers[family(scheme)]
+		}
+		if !ok && p.passthrough {
+			continue
+		}
+		if p.validate != nil {
+			for _, k := range p.keys {
+				if v := q.Get(k); q.Has(k) && !p.validate(v) {
+					return &ParamError{Driver: scheme.Driver, Param: k, Value: v, Err: ErrInvalidParameter}
+				}
+			}
+		}
+		out := make(url.Values)
+		if f != nil {
+			if err := f(q, out); err != nil {
+				if e, ok := err.(*ParamError); ok && e.Driver == "" {
+					e.Driver = scheme.Driver
+				}
+				return err
+			}
+		}
+		for _, k := range p.keys {
+			if q.Has(k) && (f == nil || !p.passthrough) {
+				return &ParamError{Driver: scheme.Driver, Param: k, Err: ErrUnsupportedParameter}
+			}
+		}
+		for k, v := range out {
+			if !q.Has(k) {
+				q[k] = v
+			}
+		}
+		changed = true
+	}
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+	return nil
+}
+
+// isPort returns true when s is a valid port number.
+func isPort(s string) bool {
+	n, err := strconv.ParseUint(s, 10, 16)
+	return err == nil && n != 0
+}
+
+// isPortableParam returns true when key is a portable query parameter.
+func isPortableParam(key string) bool {
+	if contains(openParams, key) {
+		return true
+	}
+	for _, p := range portableParams {
+		if contains(p.keys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// renameParams creates a translator that renames portable query parameters
+// (specified as pairs of portable and native names), and sets the defaults.
+func renameParams(defaults map[string]string, names ...string) translator {
+	return func(q, out url.Values) error {
+		for i := 0; i < len(names); i += 2 {
+			if v, ok := q[names[i]]; ok {
+				out[names[i+1]] = v
+				delete(q, names[i])
+			}
+		}
+		for k, v := range defaults {
+			out.Set(k, v)
+		}
+		return nil
+	}
+}
+
+// krb5Postgres creates a translator for the portable kerberos parameters of
+// the postgres drivers, setting the defaults. A service principal name (ie,
+// "postgres/host") is passed as krbspn, qualified with the realm when
+// specified, and a service name (ie, "postgres") as krbsrvname.
+//
+// The keytab and credential cache are not supported, and must instead be set
+// using the KRB5_KTNAME and KRB5CCNAME environment variables.
+func krb5Postgres(defaults map[string]string) translator {
+	return func(q, out url.Values) error {
+		if !q.Has("spn") {
+			return nil
+		}
+		switch spn, realm := q.Get("spn"), q.Get("krb5_realm"); {
+		case !strings.Contains(spn, "/"):
+			out.Set("krbsrvname", spn)
+		case realm != "" && !strings.Contains(spn, "@"):
+			out.Set("krbspn", spn+"@"+realm)
+			q.Del("krb5_realm")
+		default:
+			out.Set("krbspn", spn)
+		}
+		q.Del("spn")
+		for k, v := range defaults {
+			out.Set(k, v)
+		}
+		return nil
+	}
+}
+
+// tlsProgrammatic is a translator for drivers that only accept a TLS config
+// programmatically, validating the portable TLS parameters and removing them
+// from the DSN. The parameters remain in the URL for use with [TLSConfig].
+func tlsProgrammatic(q, out url.Values) error {
+	if _, err := tlsSkipVerify(q); err != nil {
+		return err
+	}
+	if err := tlsPolicy(q); err != nil {
+		return err
+	}
+	for _, k := range tlsKeys {
+		q.Del(k)
+	}
+	return nil
+}
+
+// passthroughParams is a translator for drivers that natively accept the
+// portable query parameters, passing them through unchanged.
+func passthroughParams(q, out url.Values) error {
+	return nil
+}
+
+// boolParam creates a translator for the boolean portable query parameter
+// key, setting the native parameter name to the on or off value. An empty on
+// value indicates the driver does not support that value, an empty off value
+// drops the parameter when off, and an empty name drops the parameter.
+//
+// Non-boolean values are passed through when the native and portable
+// parameter names are the same (ie, "compress=zstd" for clickhouse).
+func boolParam(key, name, on, off string) translator {
+	return func(q, out url.Values) error {
+		b, err := strconv.ParseBool(q.Get(key))
+		switch {
+		case err != nil && key == name:
+			out.Set(name, q.Get(key))
+			q.Del(key)
+			return nil
+		case err != nil:
+			return &ParamError{Param: key, Value: q.Get(key), Err: ErrInvalidParameter}
+		}
+		v := off
+		if b {
+			v = on
+		}
+		if v != "" && name != "" {
+			out.Set(name, v)
+		}
+		if v != "" || !b {
+			q.Del(key)
+		}
+		return nil
+	}
+}
+
+// ParamError is a portable query parameter error.
+type ParamError struct {
+	// Driver is the scheme's driver name.
+	Driver string
+	// Param is the query parameter.
+	Param string
+	// Value is the query parameter value.
+	Value string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *ParamError) Error() string {
+	if err.Err == ErrUnsupportedParameter {
+		return fmt.Sprintf("%v: %s does not support %q", err.Err, err.Driver, err.Param)
+	}
+	return fmt.Sprintf("%v: %s %s=%q", err.Err, err.Driver, err.Param, err.Value)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *ParamError) Unwrap() error {
+	return err.Err
+}
@@ -160,6 +160,17 @@ func Match(u *dburl.URL, homeDir, name string) (*url.Userinfo, error) {
 	return MatchFile(u, Path(homeDir, name), dburl.Protocols(u.Driver)...)
 }
 
+// Resolver returns a password resolver for use with
+// [dburl.RegisterPasswordResolver], matching URLs against the entries of the
+// passfile in $HOME/.<name> or $ENV{NAME}.
+//
+// Equivalent to Match(u, homeDir, name) for each URL.
+func Resolver(homeDir, name string) dburl.PasswordResolver {
+	return func(u *dburl.URL) (*url.Userinfo, error) {
+		return Match(u, homeDir, name)
+	}
+}
+
 // MatchProtocols returns a Userinfo from a passfile entry matching database
 // URL read from the file in $HOME/.<name> or $ENV{NAME} using the specified
 // protocols.
@@ -204,16 +215,19 @@ func Expand(homeDir string, file string) string {
 // passfile in the home directory.
 func OpenURL(u *dburl.URL, homeDir, name string) (*sql.DB, error) {
 	if u.User != nil {
-		return sql.Open(u.Driver, u.DSN)
+		return dburl.OpenURL(u)
 	}
 	user, err := Match(u, homeDir, name)
 	if err != nil {
-		return sql.Open(u.Driver, u.DSN)
+		return dburl.OpenURL(u)
 	}
 	u.User = user
-	v, _ := dburl.Parse(u.String())
+	v, err := dburl.Parse(u.String())
+	if err != nil {
+		return nil, err
+	}
 	*u = *v
-	return sql.Open(v.Driver, v.DSN)
+	return dburl.OpenURL(u)
 }
 
 // Open opens a database connection for a URL, reading the named passfile in
@@ -0,0 +1,72 @@
+package dburl
+
+import (
+	"database/sql"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Connection pool query parameters, applied to the [sql.DB] returned by
+// [Open] and [OpenURL].
+const (
+	// maxOpenConnsParam is the query parameter specifying the maximum number
+	// of open connections (see [sql.DB.SetMaxOpenConns]).
+	maxOpenConnsParam = "dburl_max_open_conns"
+	// maxIdleConnsParam is the query parameter specifying the maximum number
+	// of idle connections (see [sql.DB.SetMaxIdleConns]).
+	maxIdleConnsParam = "dburl_max_idle_conns"
+	// connMaxLifetimeParam is the query parameter specifying the maximum
+	// amount of time a connection may be reused (see
+	// [sql.DB.SetConnMaxLifetime]).
+	connMaxLifetimeParam = "dburl_conn_max_lifetime"
+	// connMaxIdleTimeParam is the query parameter specifying the maximum
+	// amount of time a connection may be idle (see
+	// [sql.DB.SetConnMaxIdleTime]).
+	connMaxIdleTimeParam = "dburl_conn_max_idle_time"
+)
+
+// poolParams are the connection pool query parameters.
+var poolParams = []string{maxOpenConnsParam, maxIdleConnsParam, connMaxLifetimeParam, connMaxIdleTimeParam}
+
+// validatePool validates the connection pool query parameters, returning a
+// [ParamError] for the first invalid value.
+func validatePool(q url.Values, driver string) error {
+	for _, k := range poolParams {
+		if v := q.Get(k); q.Has(k) && !isPoolValue(k, v) {
+			return &ParamError{Driver: driver, Param: k, Value: v, Err: ErrInvalidParameter}
+		}
+	}
+	return nil
+}
+
+// isPoolValue returns true when v is a valid value for the connection pool
+// query parameter k: a non-negative integer count, or a non-negative duration
+// (ie, "30m").
+func isPoolValue(k, v string) bool {
+	switch k {
+	case maxOpenConnsParam, maxIdleConnsParam:
+		n, err := strconv.Atoi(v)
+		return err == nil && n >= 0
+	}
+	d, err := time.ParseDuration(v)
+	return err == nil && d >= 0
+}
+
+// configurePool applies the URL's connection pool query parameters to the
+// database.
+func configurePool(db *sql.DB, u *URL) {
+	q := u.Query()
+	if n, err := strconv.Atoi(q.Get(maxOpenConnsParam)); err == nil {
+		db.SetMaxOpenConns(n)
+	}
+	if n, err := strconv.Atoi(q.Get(maxIdleConnsParam)); err == nil {
+		db.SetMaxIdleConns(n)
+	}
+	if d, err := time.ParseDuration(q.Get(connMaxLifetimeParam)); err == nil {
+		db.SetConnMaxLifetime(d)
+	}
+	if d, err := time.ParseDuration(q.Get(connMaxIdleTimeParam)); err == nil {
+		db.SetConnMaxIdleTime(d)
+	}
+}
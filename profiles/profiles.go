@@ -0,0 +1,400 @@
+// Package profiles provides a mechanism for resolving named database
+// connection profiles, read from a profiles file, into database URLs.
+//
+// A profiles file is a TOML file where each table is a named profile:
+//
+//	# ~/.config/dburl/profiles.toml
+//	[prod-orders]
+//	url = "pg://orders.db.example.com/orders?sslmode=require"
+//	user = "orders"
+//	passfile = "~/.usqlpass"
+//
+//	["dev.orders"]
+//	url = "pg://localhost/orders"
+//	user = "postgres"
+//	password = "P4ssw0rd"
+//
+// Profiles are referenced with "profile://<name>" URLs (ie,
+// "profile://prod-orders"). Only the subset of TOML consisting of tables and
+// string values is supported.
+package profiles
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/dburl/passfile"
+)
+
+// Profile is a named connection profile.
+type Profile struct {
+	// Name is the profile name.
+	Name string
+	// URL is the database URL.
+	URL string
+	// User is the user name, overriding the user in the URL.
+	User string
+	// Password is the password, overriding the password in the URL.
+	Password string
+	// Passfile is the passfile to read the password from when no password
+	// is specified.
+	Passfile string
+}
+
+// Parse parses profiles from the reader.
+func Parse(r io.Reader) ([]Profile, error) {
+	var profiles []Profile
+	var profile *Profile
+	i, s := 0, bufio.NewScanner(r)
+	for s.Scan() {
+		i++
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			// table
+			end := strings.LastIndex(line, "]")
+			if end == -1 || !isComment(line[end+1:]) {
+				return nil, &LineError{i, ErrInvalidTable}
+			}
+			name, err := parseKey(strings.TrimSpace(line[1:end]))
+			if err != nil {
+				return nil, &LineError{i, err}
+			}
+			for _, p := range profiles {
+				if p.Name == name {
+					return nil, &LineError{i, ErrDuplicateProfile}
+				}
+			}
+			profiles = append(profiles, Profile{Name: name})
+			profile = &profiles[len(profiles)-1]
+			continue
+		case profile == nil:
+			return nil, &LineError{i, ErrMissingTable}
+		}
+		// key = value
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, &LineError{i, ErrInvalidValue}
+		}
+		key, err := parseKey(strings.TrimSpace(k))
+		if err != nil {
+			return nil, &LineError{i, err}
+		}
+		value, err := parseString(strings.TrimSpace(v))
+		if err != nil {
+			return nil, &LineError{i, err}
+		}
+		switch key {
+		case "url":
+			profile.URL = value
+		case "user":
+			profile.User = value
+		case "password":
+			profile.Password = value
+		case "passfile":
+			profile.Passfile = value
+		default:
+			return nil, &LineError{i, fmt.Errorf("unknown key %q", key)}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// parseKey parses a bare or quoted key.
+func parseKey(s string) (string, error) {
+	switch {
+	case s == "":
+		return "", ErrInvalidKey
+	case s[0] == '"' || s[0] == '\'':
+		return parseString(s)
+	}
+	for _, c := range s {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return "", ErrInvalidKey
+		}
+	}
+	return s, nil
+}
+
+// parseString parses a basic ("...") or literal ('...') string, followed by
+// an optional comment.
+func parseString(s string) (string, error) {
+	if s == "" {
+		return "", ErrInvalidValue
+	}
+	switch s[0] {
+	case '"':
+		// find closing quote, skipping escapes
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				if !isComment(s[i+1:]) {
+					return "", ErrInvalidValue
+				}
+				v, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", ErrInvalidValue
+				}
+				return v, nil
+			}
+		}
+	case '\'':
+		if i := strings.IndexByte(s[1:], '\''); i != -1 && isComment(s[i+2:]) {
+			return s[1 : i+1], nil
+		}
+	}
+	return "", ErrInvalidValue
+}
+
+// isComment returns true when s is empty or a comment.
+func isComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#")
+}
+
+// ParseFile parses profiles contained in file.
+//
+// Returns no profiles and no error when the file does not exist.
+func ParseFile(file string) ([]Profile, error) {
+	fi, err := os.Stat(file)
+	switch {
+	case err != nil && os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, &FileError{file, err}
+	case fi.IsDir():
+		return nil, &FileError{file, passfile.ErrMustNotBeDirectory}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, &FileError{file, err}
+	}
+	defer f.Close()
+	profiles, err := Parse(f)
+	if err != nil {
+		return nil, &FileError{file, err}
+	}
+	return profiles, nil
+}
+
+// Path returns the path to the profiles file.
+//
+// Uses $DBURL_PROFILES, $XDG_CONFIG_HOME/dburl/profiles.toml, or
+// <homeDir>/.config/dburl/profiles.toml (on Windows,
+// %APPDATA%\dburl\profiles.toml).
+func Path(homeDir string) string {
+	if s := os.Getenv("DBURL_PROFILES"); s != "" {
+		return passfile.Expand(homeDir, s)
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	switch {
+	case dir == "" && runtime.GOOS == "windows" && os.Getenv("APPDATA") != "":
+		dir = os.Getenv("APPDATA")
+	case dir == "":
+		dir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(dir, "dburl", "profiles.toml")
+}
+
+// Lookup returns the named profile.
+func Lookup(profiles []Profile, name string) (Profile, bool) {
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// Resolve resolves a "profile://<name>" URL string using the profiles,
+// returning the profile's parsed URL. Any query parameters on the profile URL
+// string override the profile URL's query parameters. URL strings with other
+// schemes are parsed as-is.
+//
+// When the profile does not specify a password and has a passfile, the
+// password is read from the passfile.
+func Resolve(profiles []Profile, homeDir, urlstr string) (*dburl.URL, error) {
+	if !strings.HasPrefix(urlstr, "profile:") {
+		return dburl.Parse(urlstr)
+	}
+	v, err := url.Parse(urlstr)
+	if err != nil {
+		return nil, err
+	}
+	name := v.Host
+	if name == "" {
+		name = strings.TrimPrefix(v.Opaque, "//")
+	}
+	p, ok := Lookup(profiles, name)
+	if !ok {
+		return nil, &ProfileError{name, ErrUnknownProfile}
+	}
+	if p.URL == "" {
+		return nil, &ProfileError{name, ErrMissingURL}
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, &ProfileError{name, err}
+	}
+	// user and password
+	if p.User != "" || p.Password != "" {
+		user := p.User
+		if user == "" && u.User != nil {
+			user = u.User.Username()
+		}
+		if p.Password != "" {
+			u.User = url.UserPassword(user, p.Password)
+		} else {
+			u.User = url.User(user)
+		}
+	}
+	// query overrides
+	if q := v.Query(); len(q) != 0 {
+		z := u.Query()
+		for k, v := range q {
+			z[k] = v
+		}
+		u.RawQuery = z.Encode()
+	}
+	d, err := dburl.Parse(u.String())
+	if err != nil {
+		return nil, &ProfileError{name, err}
+	}
+	// passfile
+	if _, ok := userPassword(d); !ok && p.Passfile != "" {
+		user, err := passfile.MatchFile(d, passfile.Expand(homeDir, p.Passfile), dburl.Protocols(d.Driver)...)
+		switch {
+		case err != nil:
+			return nil, &ProfileError{name, err}
+		case user != nil:
+			d.User = user
+			if d, err = dburl.Parse(d.String()); err != nil {
+				return nil, &ProfileError{name, err}
+			}
+		}
+	}
+	return d, nil
+}
+
+// userPassword returns the URL's password.
+func userPassword(u *dburl.URL) (string, bool) {
+	if u.User == nil {
+		return "", false
+	}
+	return u.User.Password()
+}
+
+// ResolveFile resolves a "profile://<name>" URL string using the profiles
+// contained in file. See [Resolve].
+func ResolveFile(file, homeDir, urlstr string) (*dburl.URL, error) {
+	if !strings.HasPrefix(urlstr, "profile:") {
+		return dburl.Parse(urlstr)
+	}
+	profiles, err := ParseFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Resolve(profiles, homeDir, urlstr)
+}
+
+// Open opens a database connection for a URL string, resolving
+// "profile://<name>" URLs using the profiles file in the home directory.
+//
+// Equivalent to ResolveFile(Path(homeDir), homeDir, urlstr) followed by
+// [dburl.OpenURL].
+func Open(homeDir, urlstr string) (*sql.DB, error) {
+	u, err := ResolveFile(Path(homeDir), homeDir, urlstr)
+	if err != nil {
+		return nil, err
+	}
+	return dburl.OpenURL(u)
+}
+
+// Error is a error.
+type Error string
+
+// Error satisfies the error interface.
+func (err Error) Error() string {
+	return string(err)
+}
+
+const (
+	// ErrInvalidTable is the invalid table error.
+	ErrInvalidTable Error = "invalid table"
+	// ErrInvalidKey is the invalid key error.
+	ErrInvalidKey Error = "invalid key"
+	// ErrInvalidValue is the invalid value error.
+	ErrInvalidValue Error = "invalid value"
+	// ErrMissingTable is the missing table error.
+	ErrMissingTable Error = "missing table"
+	// ErrDuplicateProfile is the duplicate profile error.
+	ErrDuplicateProfile Error = "duplicate profile"
+	// ErrUnknownProfile is the unknown profile error.
+	ErrUnknownProfile Error = "unknown profile"
+	// ErrMissingURL is the missing url error.
+	ErrMissingURL Error = "missing url"
+)
+
+// FileError is a file error.
+type FileError struct {
+	File string
+	Err  error
+}
+
+// Error satisfies the error interface.
+func (err *FileError) Error() string {
+	return fmt.Sprintf("profiles %q: %v", err.File, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *FileError) Unwrap() error {
+	return err.Err
+}
+
+// LineError is a line error.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+// Error satisfies the error interface.
+func (err *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *LineError) Unwrap() error {
+	return err.Err
+}
+
+// ProfileError is a profile error.
+type ProfileError struct {
+	Name string
+	Err  error
+}
+
+// Error satisfies the error interface.
+func (err *ProfileError) Error() string {
+	return fmt.Sprintf("profile %q: %v", err.Name, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *ProfileError) Unwrap() error {
+	return err.Err
+}
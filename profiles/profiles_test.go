@@ -0,0 +1,108 @@
+package profiles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	profiles, err := Parse(strings.NewReader(profilesFile))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := []Profile{
+		{"prod-orders", "pg://orders.db.example.com/orders?sslmode=require", "orders", "", "~/.usqlpass"},
+		{"dev.orders", "pg://localhost/orders", "postgres", `P4ss"w0rd`, ""},
+		{"mysql", `my://localhost/mysql`, "", "", ""},
+	}
+	if !reflect.DeepEqual(profiles, exp) {
+		t.Errorf("profiles does not equal expected:\nexp:%#v\n---\ngot:%#v", exp, profiles)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		s    string
+		line int
+		exp  error
+	}{
+		{"url = \"pg://\"", 1, ErrMissingTable},
+		{"[a]\n[a]", 2, ErrDuplicateProfile},
+		{"[a b]", 1, ErrInvalidKey},
+		{"[a", 1, ErrInvalidTable},
+		{"[a]\nurl = pg://", 2, ErrInvalidValue},
+		{"[a]\nurl = \"pg://\" extra", 2, ErrInvalidValue},
+		{"[a]\nurl", 2, ErrInvalidValue},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.s))
+			var lerr *LineError
+			switch {
+			case !errors.As(err, &lerr):
+				t.Fatalf("expected line error, got: %v", err)
+			case lerr.Line != test.line:
+				t.Errorf("expected line %d, got: %d", test.line, lerr.Line)
+			case !errors.Is(err, test.exp):
+				t.Errorf("expected %v, got: %v", test.exp, err)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".usqlpass"), []byte("postgres:*:*:*:orders:0rd3rs\n"), 0o600); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	profiles, err := Parse(strings.NewReader(profilesFile))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tests := []struct {
+		s   string
+		exp string
+		dsn string
+	}{
+		{`profile://prod-orders`, `pg://orders:0rd3rs@orders.db.example.com/orders?sslmode=require`, `dbname=orders host=orders.db.example.com password=0rd3rs sslmode=require user=orders`},
+		{`profile://dev.orders?sslmode=disable`, `pg://postgres:P4ss%22w0rd@localhost/orders?sslmode=disable`, `dbname=orders host=localhost password=P4ss"w0rd sslmode=disable user=postgres`},
+		{`profile:mysql`, `my://localhost/mysql`, `tcp(localhost:3306)/mysql`},
+		{`pg://localhost/other`, `pg://localhost/other`, `dbname=other host=localhost`},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			u, err := Resolve(profiles, dir, test.s)
+			switch {
+			case err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			case u.String() != test.exp:
+				t.Errorf("expected %q, got: %q", test.exp, u.String())
+			case u.DSN != test.dsn:
+				t.Errorf("expected dsn %q, got: %q", test.dsn, u.DSN)
+			}
+		})
+	}
+	if _, err := Resolve(profiles, dir, "profile://missing"); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got: %v", err)
+	}
+}
+
+const profilesFile = `# sample profiles file
+[prod-orders]
+url = "pg://orders.db.example.com/orders?sslmode=require"
+user = "orders" # comment
+passfile = '~/.usqlpass'
+
+["dev.orders"]
+url = "pg://localhost/orders"
+user = "postgres"
+password = "P4ss\"w0rd"
+
+[mysql]
+url = 'my://localhost/mysql'
+`
@@ -0,0 +1,108 @@
+package dburl
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"sync"
+)
+
+// PasswordResolver is a func that resolves the user name and password for a
+// URL without a password (ie, from a passfile), returning nil when no
+// password is available. See [RegisterPasswordResolver].
+type PasswordResolver func(*URL) (*url.Userinfo, error)
+
+// passwordResolvers are the registered password resolvers.
+var passwordResolvers struct {
+	sync.RWMutex
+	resolvers []PasswordResolver
+}
+
+// RegisterPasswordResolver registers a password resolver used by
+// [OpenWithPrompt]. Resolvers are called in the order registered, until a
+// resolver returns a password. For example, to use passwords from the
+// ~/.usqlpass passfile:
+//
+//	dburl.RegisterPasswordResolver(passfile.Resolver(homeDir, "usqlpass"))
+func RegisterPasswordResolver(resolver PasswordResolver) {
+	if resolver == nil {
+		panic("must specify a non-nil PasswordResolver")
+	}
+	passwordResolvers.Lock()
+	defer passwordResolvers.Unlock()
+	passwordResolvers.resolvers = append(passwordResolvers.resolvers, resolver)
+}
+
+// OpenWithPrompt parses the URL string and opens a standard [sql.DB]
+// connection, calling prompt for the password when no password is available
+// from the URL, the URL's "credentials_dir" (see [ResolveCredentials]), or
+// the registered password resolvers (see [RegisterPasswordResolver]). Gives
+// interactive tools consistent prompting behavior across schemes.
+//
+// The prompt is not called for schemes without user info (ie, file based
+// databases). An empty password returned by prompt is not used.
+func OpenWithPrompt(urlstr string, prompt func(u *URL) (string, error)) (*sql.DB, error) {
+	u, err := Parse(urlstr)
+	if err != nil {
+		return nil, err
+	}
+	if u, err = resolvePassword(u, prompt); err != nil {
+		return nil, err
+	}
+	return OpenURL(u)
+}
+
+// resolvePassword returns a new URL with the password resolved from the
+// URL's "credentials_dir", the registered password resolvers, or prompt, when
+// the URL does not have a password.
+func resolvePassword(u *URL, prompt func(u *URL) (string, error)) (*URL, error) {
+	u, err := ResolveCredentials(u)
+	if err != nil || !needsPassword(u) {
+		return u, err
+	}
+	passwordResolvers.RLock()
+	resolvers := passwordResolvers.resolvers
+	passwordResolvers.RUnlock()
+	for _, resolver := range resolvers {
+		user, err := resolver(u)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := user.Password(); ok {
+			return u.withUser(user)
+		}
+	}
+	pass, err := prompt(u)
+	if err != nil || pass == "" {
+		return u, err
+	}
+	var name string
+	if u.User != nil {
+		name = u.User.Username()
+	}
+	return u.withUser(url.UserPassword(name, pass))
+}
+
+// needsPassword returns true when the URL does not have a password, and the
+// URL's scheme uses user info.
+func needsPassword(u *URL) bool {
+	if scheme, ok := lookupScheme(u.UnaliasedDriver); !ok || scheme.Opaque {
+		return false
+	}
+	if u.User == nil {
+		return true
+	}
+	_, ok := u.User.Password()
+	return !ok
+}
+
+// withUser returns a new URL with the user info.
+func (u *URL) withUser(user *url.Userinfo) (*URL, error) {
+	z := u.URL
+	z.User = user
+	o := u.opts
+	if o == nil {
+		o = newOptions(context.Background())
+	}
+	return parse((&URL{URL: z, OriginalScheme: u.OriginalScheme}).String(), o)
+}
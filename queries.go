@@ -0,0 +1,145 @@
+package dburl
+
+import (
+	"context"
+	"database/sql"
+)
+
+// query returns the query of the URL's driver, using the Override scheme's
+// query when the driver's scheme does not have one.
+func (u *URL) query(f func(*Scheme) string) string {
+	scheme := u.driverScheme(func(scheme *Scheme) bool {
+		return f(scheme) != ""
+	})
+	if scheme == nil {
+		return ""
+	}
+	return f(scheme)
+}
+
+// PingQuery returns the query used by [Ping] to check the connection for the
+// URL's driver, if any.
+func (u *URL) PingQuery() string {
+	return u.query(func(scheme *Scheme) string {
+		return scheme.PingQuery
+	})
+}
+
+// Ping checks the database connection, using the ping query of the URL's
+// driver (ie, "SELECT 1", "SELECT 1 FROM DUAL"), as [sql.DB.PingContext] is a
+// no-op or unsupported by some drivers. When the driver does not have a ping
+// query, [sql.DB.PingContext] is used.
+func Ping(ctx context.Context, db *sql.DB, u *URL) error {
+	query := u.PingQuery()
+	if query == "" {
+		return db.PingContext(ctx)
+	}
+	var v interface{}
+	return db.QueryRowContext(ctx, query).Scan(&v)
+}
+
+// VersionQuery returns the query used by [ServerVersion] to retrieve the
+// server version for the URL's driver, if any.
+func (u *URL) VersionQuery() string {
+	return u.query(func(scheme *Scheme) string {
+		return scheme.VersionQuery
+	})
+}
+
+// ServerVersion returns the database server version, using the version query
+// of the URL's driver (ie, "SELECT version()", "SELECT @@VERSION"). A [QueryError]
+// is returned when the driver does not have a version query.
+func ServerVersion(ctx context.Context, db *sql.DB, u *URL) (string, error) {
+	return queryString(ctx, db, u, "version", u.VersionQuery())
+}
+
+// DatabasesQuery returns the query used by [ListDatabases] for the URL's
+// driver, if any.
+func (u *URL) DatabasesQuery() string {
+	return u.query(func(scheme *Scheme) string {
+		return scheme.DatabasesQuery
+	})
+}
+
+// ListDatabases returns the names of the databases, using the databases query
+// of the URL's driver. A [QueryError] is returned when the driver does not
+// have a databases query.
+func ListDatabases(ctx context.Context, db *sql.DB, u *URL) ([]string, error) {
+	return queryStrings(ctx, db, u, "databases", u.DatabasesQuery())
+}
+
+// SchemasQuery returns the query used by [ListSchemas] for the URL's driver,
+// if any.
+func (u *URL) SchemasQuery() string {
+	return u.query(func(scheme *Scheme) string {
+		return scheme.SchemasQuery
+	})
+}
+
+// ListSchemas returns the names of the schemas, using the schemas query of
+// the URL's driver. A [QueryError] is returned when the driver does not have a
+// schemas query.
+func ListSchemas(ctx context.Context, db *sql.DB, u *URL) ([]string, error) {
+	return queryStrings(ctx, db, u, "schemas", u.SchemasQuery())
+}
+
+// CurrentDatabaseQuery returns the query used by [CurrentDatabase] for the
+// URL's driver, if any.
+func (u *URL) CurrentDatabaseQuery() string {
+	return u.query(func(scheme *Scheme) string {
+		return scheme.CurrentDatabaseQuery
+	})
+}
+
+// CurrentDatabase returns the name of the current database, using the current
+// database query of the URL's driver. A [QueryError] is returned when the
+// driver does not have a current database query.
+func CurrentDatabase(ctx context.Context, db *sql.DB, u *URL) (string, error) {
+	return queryString(ctx, db, u, "current database", u.CurrentDatabaseQuery())
+}
+
+// queryString runs the named query, returning the single string result.
+func queryString(ctx context.Context, db *sql.DB, u *URL, name, query string) (string, error) {
+	if query == "" {
+		return "", &QueryError{Driver: u.UnaliasedDriver, Name: name}
+	}
+	var s sql.NullString
+	if err := db.QueryRowContext(ctx, query).Scan(&s); err != nil {
+		return "", err
+	}
+	return s.String, nil
+}
+
+// queryStrings runs the named query, returning the first column of the
+// results.
+func queryStrings(ctx context.Context, db *sql.DB, u *URL, name, query string) ([]string, error) {
+	if query == "" {
+		return nil, &QueryError{Driver: u.UnaliasedDriver, Name: name}
+	}
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	dest := make([]interface{}, max(len(cols), 1))
+	for i := range dest {
+		dest[i] = new(interface{})
+	}
+	var v []string
+	for rows.Next() {
+		var s sql.NullString
+		dest[0] = &s
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		v = append(v, s.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,108 @@
+package dburl
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Placeholder is a query placeholder style.
+type Placeholder uint
+
+// Placeholder styles.
+const (
+	// PlaceholderDefault is the default placeholder style, which is the style
+	// of the scheme's Override, or PlaceholderQuestion.
+	PlaceholderDefault Placeholder = iota
+	// PlaceholderQuestion is the "?" placeholder style.
+	PlaceholderQuestion
+	// PlaceholderDollar is the "$1" placeholder style.
+	PlaceholderDollar
+	// PlaceholderColon is the ":1" placeholder style.
+	PlaceholderColon
+	// PlaceholderAt is the "@p1" placeholder style.
+	PlaceholderAt
+)
+
+// placeholderNames are the names of the placeholder styles.
+var placeholderNames = []string{
+	PlaceholderDefault:  "",
+	PlaceholderQuestion: "question",
+	PlaceholderDollar:   "dollar",
+	PlaceholderColon:    "colon",
+	PlaceholderAt:       "at",
+}
+
+// String satisfies the [fmt.Stringer] interface.
+func (p Placeholder) String() string {
+	if int(p) < len(placeholderNames) {
+		return placeholderNames[p]
+	}
+	return "Placeholder(" + strconv.Itoa(int(p)) + ")"
+}
+
+// prefix returns the placeholder prefix.
+func (p Placeholder) prefix() string {
+	switch p {
+	case PlaceholderDollar:
+		return "$"
+	case PlaceholderColon:
+		return ":"
+	case PlaceholderAt:
+		return "@p"
+	}
+	return ""
+}
+
+// parsePlaceholder parses the placeholder style name.
+func parsePlaceholder(name string) (Placeholder, bool) {
+	for i, s := range placeholderNames {
+		if strings.EqualFold(name, s) {
+			return Placeholder(i), true
+		}
+	}
+	return PlaceholderDefault, false
+}
+
+// Placeholder returns the query placeholder style of the URL's driver.
+func (u *URL) Placeholder() Placeholder {
+	scheme := u.driverScheme(func(scheme *Scheme) bool {
+		return scheme.Placeholder != PlaceholderDefault
+	})
+	if scheme == nil || scheme.Placeholder == PlaceholderDefault {
+		return PlaceholderQuestion
+	}
+	return scheme.Placeholder
+}
+
+// Rebind converts the "?" placeholders in the query to the placeholder style
+// of the URL's driver (ie, "$1" for postgres, ":1" for oracle, "@p1" for
+// sqlserver). Placeholders in quoted strings and identifiers are not
+// converted.
+func Rebind(u *URL, query string) string {
+	style := u.Placeholder()
+	if style == PlaceholderQuestion || !strings.Contains(query, "?") {
+		return query
+	}
+	prefix := style.prefix()
+	var sb strings.Builder
+	sb.Grow(len(query) + 8)
+	var quote byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'', c == '"', c == '`':
+			quote = c
+		case c == '?':
+			n++
+			sb.WriteString(prefix)
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteByte(query[i])
+	}
+	return sb.String()
+}
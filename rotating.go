@@ -0,0 +1,96 @@
+package dburl
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+)
+
+// ResolveFunc is a func that resolves the user name and password to use for a
+// new connection.
+type ResolveFunc func(context.Context) (string, string, error)
+
+// OpenRotating parses the URL string and opens a standard [sql.DB] backed by
+// a [driver.Connector] that calls resolve for each new connection, using the
+// returned user name and password to generate the connection's DSN. Allows
+// short-lived credentials (ie, Vault dynamic secrets, IAM tokens) to be used
+// without restarting the connection pool.
+//
+// When resolve returns an empty user name, the URL's user name is used. As
+// with [OpenURL], the URL's TLS config is registered with the driver (see
+// [RegisterTLSConfigFunc]), and the connection pool is configured with the
+// URL's "dburl_*" query parameters. Open hooks are not applied.
+func OpenRotating(urlstr string, resolve ResolveFunc) (*sql.DB, error) {
+	u, err := Parse(urlstr)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareOpen(u); err != nil {
+		return nil, err
+	}
+	name := u.Driver
+	if u.GoDriver != "" {
+		name = u.GoDriver
+	}
+	// sql.Open does not connect, and is the only way to retrieve a
+	// registered driver
+	db, err := sql.Open(name, u.DSN)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+	db = sql.OpenDB(&rotatingConnector{u: u, drv: drv, resolve: resolve})
+	configurePool(db, u)
+	return db, nil
+}
+
+// rotatingConnector is a connector that resolves credentials for each new
+// connection.
+type rotatingConnector struct {
+	u       *URL
+	drv     driver.Driver
+	resolve ResolveFunc
+}
+
+// Connect satisfies the [driver.Connector] interface.
+func (c *rotatingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	user, pass, err := c.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	z := c.u.URL
+	if user == "" && z.User != nil {
+		user = z.User.Username()
+	}
+	if pass != "" {
+		z.User = url.UserPassword(user, pass)
+	} else {
+		z.User = url.User(user)
+	}
+	o := newOptions(ctx)
+	if c.u.opts != nil {
+		*o = *c.u.opts
+		o.ctx = ctx
+	}
+	u, err := parse((&URL{URL: z, OriginalScheme: c.u.OriginalScheme}).String(), o)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := c.drv.(driver.DriverContext); ok {
+		connector, err := d.OpenConnector(u.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	return c.drv.Open(u.DSN)
+}
+
+// Driver satisfies the [driver.Connector] interface.
+func (c *rotatingConnector) Driver() driver.Driver {
+	return c.drv
+}
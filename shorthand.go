@@ -0,0 +1,92 @@
+package dburl
+
+import (
+	"context"
+	"net/url"
+)
+
+// ShorthandFunc is a func that expands a shorthand URL string (ie, a bare
+// file path) into a URL string with a scheme. See [WithShorthand].
+type ShorthandFunc func(s string) (string, error)
+
+// ExpandShorthand expands a shorthand URL string into a URL string with a
+// scheme, using the same rules as [Parse], additionally guessing the scheme of
+// host strings from the port (see [WithGuessScheme]):
+//
+//	:memory:                       in-memory sqlite3 database ("sqlite3::memory:")
+//	/path/to/file.db               file database, by file header or extension (see [SchemeType])
+//	/var/run/postgresql            unix domain socket or socket directory (see [SchemeType])
+//	[user@]host:port[/dbname]      scheme guessed from the port (see [GuessScheme])
+//
+// URL strings with a scheme are returned unchanged. Returns
+// [ErrInvalidDatabaseScheme] when the string cannot be expanded. File paths
+// are only expanded when [ResolveSchemeType] is true.
+//
+// Useful for tools that want to display or log the expanded URL, or that
+// customize the expansion used by [ParseWithOptions] (see [WithShorthand]).
+func ExpandShorthand(s string) (string, error) {
+	return newOptions(context.Background(), WithGuessScheme()).expandShorthand(s)
+}
+
+// WithShorthand is a parse option to use the func to expand shorthand URL
+// strings, instead of the default rules (see [ExpandShorthand]). The func is
+// called for all URL strings, and should return URL strings with a scheme
+// unchanged.
+func WithShorthand(f ShorthandFunc) Option {
+	return func(o *options) {
+		o.shorthand = f
+	}
+}
+
+// WithoutShorthand is a parse option to disable expanding shorthand URL
+// strings, requiring all URL strings to have a scheme.
+func WithoutShorthand() Option {
+	return WithShorthand(func(s string) (string, error) {
+		return s, nil
+	})
+}
+
+// shorthandString expands the URL string using the shorthand func, or the
+// default rules.
+func (o *options) shorthandString(s string) (string, error) {
+	if o.shorthand != nil {
+		return o.shorthand(s)
+	}
+	return o.expandShorthand(s)
+}
+
+// expandShorthand expands the URL string using the default rules, using the
+// options' default scheme, and file system funcs.
+func (o *options) expandShorthand(s string) (string, error) {
+	// add default scheme
+	if (o.defaultScheme != "" || o.guessScheme) && isHostString(s, o) {
+		scheme := o.defaultScheme
+		if v, err := url.Parse("//" + s); o.guessScheme && err == nil {
+			if names := GuessScheme(v.Port()); len(names) != 0 {
+				scheme = names[0]
+			}
+		}
+		if scheme != "" {
+			return scheme + "://" + s, nil
+		}
+	}
+	if s == ":memory:" {
+		return "sqlite3:" + s, nil
+	}
+	z, _, err := cutHosts(s)
+	if err != nil {
+		return "", err
+	}
+	v, err := url.Parse(z)
+	switch {
+	case err != nil:
+		return "", err
+	case v.Scheme != "":
+		return s, nil
+	case ResolveSchemeType:
+		if typ, err := o.schemeType(s); err == nil {
+			return typ + ":" + s, nil
+		}
+	}
+	return "", ErrInvalidDatabaseScheme
+}
@@ -0,0 +1,95 @@
+package dburl
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// srvTargetsParam is the query parameter specifying which SRV record targets
+// are used ("first" or "all") when resolving a "+srv" URL.
+const srvTargetsParam = "srv_targets"
+
+// multiHostDrivers are the drivers accepting multiple comma separated
+// "host:port" targets in the URL's host.
+var multiHostDrivers = []string{"pgx"}
+
+// LookupSRV is the default SRV lookup func.
+//
+// Used internally to resolve the SRV records of "+srv" URLs (ie,
+// "pg+srv://_postgres._tcp.service.consul/db"). Can be overridden to use a
+// different resolver, or in tests.
+var LookupSRV = func(ctx context.Context, name string) ([]*net.SRV, error) {
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	return addrs, err
+}
+
+// ResolveSRV returns a new URL with the host and port of a "+srv" URL's
+// host resolved from its SRV records (see [LookupSRV]), as commonly used with
+// Consul and Kubernetes. When the URL is not a "+srv" URL, the URL is
+// returned unchanged.
+//
+// The target with the highest priority is used, unless the URL's
+// "srv_targets" query parameter is "all", in which case all targets are used
+// in priority order (only supported by drivers accepting multiple hosts, ie,
+// pgx). Returns [ErrNoSRVRecords] when the lookup returns no records.
+//
+// Called by [OpenURL], so that the current SRV records are used for each
+// Open.
+func ResolveSRV(ctx context.Context, u *URL) (*URL, error) {
+	if !isSRV(u) {
+		return u, nil
+	}
+	q := u.Query()
+	all := false
+	switch v := q.Get(srvTargetsParam); v {
+	case "", "first":
+	case "all":
+		if !contains(multiHostDrivers, u.UnaliasedDriver) {
+			return nil, &ParamError{Driver: u.UnaliasedDriver, Param: srvTargetsParam, Value: v, Err: ErrUnsupportedParameter}
+		}
+		all = true
+	default:
+		return nil, &ParamError{Driver: u.UnaliasedDriver, Param: srvTargetsParam, Value: v, Err: ErrInvalidParameter}
+	}
+	addrs, err := LookupSRV(ctx, u.Hostname())
+	switch {
+	case err != nil:
+		return nil, err
+	case len(addrs) == 0:
+		return nil, ErrNoSRVRecords
+	case !all:
+		addrs = addrs[:1]
+	}
+	hosts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		hosts[i] = net.JoinHostPort(strings.TrimSuffix(addr.Target, "."), strconv.Itoa(int(addr.Port)))
+	}
+	z := u.URL
+	z.Host = strings.Join(hosts, ",")
+	if q.Has(srvTargetsParam) {
+		q.Del(srvTargetsParam)
+		z.RawQuery = q.Encode()
+	}
+	o := u.opts
+	if o == nil {
+		o = newOptions(ctx)
+	}
+	scheme := u.OriginalScheme[:len(u.OriginalScheme)-len("+srv")]
+	return parse((&URL{URL: z, OriginalScheme: scheme}).String(), o)
+}
+
+// isSRV returns true when the URL is a "+srv" URL.
+func isSRV(u *URL) bool {
+	return strings.HasSuffix(strings.ToLower(u.OriginalScheme), "+srv")
+}
+
+// allowsSRV returns true when the scheme allows "+srv" URLs.
+func allowsSRV(scheme *Scheme) bool {
+	switch wireFamily(scheme) {
+	case "mysql", "postgres":
+		return !scheme.Opaque
+	}
+	return false
+}
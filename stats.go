@@ -0,0 +1,128 @@
+package dburl
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Stat counter names.
+const (
+	// StatParse counts successful parses, by scheme driver name.
+	StatParse = "parse"
+	// StatOpen counts successful opens, by driver name.
+	StatOpen = "open"
+	// StatFile counts file paths resolved to a scheme (ie, via
+	// [SchemeType]), by resolved scheme driver name.
+	StatFile = "file"
+)
+
+// StatsFunc is a func called when a stat counter (see [StatParse],
+// [StatOpen], [StatFile]) is incremented for a name.
+type StatsFunc func(counter, name string)
+
+// Stats are the collected stat counters. See [EnableStats].
+type Stats struct {
+	// Parses are the successful parses, by scheme driver name.
+	Parses map[string]int64 `json:"parses"`
+	// Opens are the successful opens, by driver name.
+	Opens map[string]int64 `json:"opens"`
+	// Files are the file paths resolved to a scheme, by resolved scheme
+	// driver name.
+	Files map[string]int64 `json:"files"`
+}
+
+// String satisfies the [fmt.Stringer] interface, returning the stats as
+// JSON. Allows publishing the stats as an [expvar.Var] using [StatsVar].
+func (st Stats) String() string {
+	buf, err := json.Marshal(st)
+	if err != nil {
+		return "{}"
+	}
+	return string(buf)
+}
+
+// StatsVar is an [expvar.Var] for the collected stats, for publishing the
+// stats without requiring this package to import expvar:
+//
+//	dburl.EnableStats(true)
+//	expvar.Publish("dburl", dburl.StatsVar{})
+type StatsVar struct{}
+
+// String satisfies the [expvar.Var] interface.
+func (StatsVar) String() string {
+	return ReadStats().String()
+}
+
+// stats are the collected stats.
+var stats struct {
+	sync.Mutex
+	enabled bool
+	funcs   []StatsFunc
+	m       map[string]map[string]int64
+}
+
+// EnableStats enables or disables collecting the stat counters returned by
+// [ReadStats]. Stats are disabled by default.
+func EnableStats(enabled bool) {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.enabled = enabled
+}
+
+// RegisterStatsFunc registers a func called when a stat counter is
+// incremented, regardless of whether stats are enabled. Useful for exporting
+// the counters to an external metrics system.
+func RegisterStatsFunc(f StatsFunc) {
+	if f == nil {
+		panic("must specify a non-nil StatsFunc")
+	}
+	stats.Lock()
+	defer stats.Unlock()
+	stats.funcs = append(stats.funcs, f)
+}
+
+// ReadStats returns a copy of the collected stat counters.
+func ReadStats() Stats {
+	stats.Lock()
+	defer stats.Unlock()
+	return Stats{
+		Parses: copyCounts(stats.m[StatParse]),
+		Opens:  copyCounts(stats.m[StatOpen]),
+		Files:  copyCounts(stats.m[StatFile]),
+	}
+}
+
+// ResetStats resets the collected stat counters.
+func ResetStats() {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.m = nil
+}
+
+// incStat increments the stat counter for the name.
+func incStat(counter, name string) {
+	stats.Lock()
+	if stats.enabled {
+		if stats.m == nil {
+			stats.m = make(map[string]map[string]int64)
+		}
+		if stats.m[counter] == nil {
+			stats.m[counter] = make(map[string]int64)
+		}
+		stats.m[counter][name]++
+	}
+	funcs := stats.funcs
+	stats.Unlock()
+	for _, f := range funcs {
+		f(counter, name)
+	}
+}
+
+// copyCounts returns a copy of the counts.
+func copyCounts(m map[string]int64) map[string]int64 {
+	v := make(map[string]int64, len(m))
+	for k, n := range m {
+		v[k] = n
+	}
+	return v
+}
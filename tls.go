@@ -0,0 +1,318 @@
+package dburl
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// tlsKeys are the portable TLS query parameters.
+var tlsKeys = []string{"tls_ca", "tls_cert", "tls_key", "tls_insecure_skip_verify", "tls_server_name", "tls_min_version", "tls_cipher_suites"}
+
+// tlsVersions are the TLS versions accepted by the portable "tls_min_version"
+// query parameter.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certFamilies are the database families accepting the "sslcert" and
+// "sslkey" (or "clientcert" and "clientkey") client certificate query
+// parameters as aliases of the portable "tls_cert" and "tls_key" parameters.
+var certFamilies = []string{"cql", "clickhouse", "mysql", "postgres", "sqlserver"}
+
+// tlsAliases are the aliases of the portable TLS query parameters.
+var tlsAliases = map[string][]string{
+	"tls_ca":   {"sslrootcert"},
+	"tls_cert": {"clientcert", "sslcert"},
+	"tls_key":  {"clientkey", "sslkey"},
+}
+
+// tlsConfigFamilies are the database families whose drivers only accept TLS
+// configs registered by name (ie, the mysql driver's "tls=<name>"), using the
+// funcs registered with [RegisterTLSConfigFunc].
+var tlsConfigFamilies = []string{"mysql"}
+
+// TLSConfigFunc is a func that registers a named TLS config with a driver (ie,
+// mysql.RegisterTLSConfig).
+type TLSConfigFunc func(name string, config *tls.Config) error
+
+// tlsConfigFuncs are the registered TLS config funcs, by driver name.
+var tlsConfigFuncs struct {
+	sync.RWMutex
+	m map[string]TLSConfigFunc
+}
+
+// RegisterTLSConfigFunc registers the func used by [Open] to register the TLS
+// config built by [TLSConfig] with a driver that only accepts TLS configs
+// registered by name (ie, mysql):
+//
+//	dburl.RegisterTLSConfigFunc("mysql", mysql.RegisterTLSConfig)
+//
+// Opening a URL with portable TLS query parameters for such a driver fails
+// with [ErrMissingTLSConfigFunc] when no func is registered.
+func RegisterTLSConfigFunc(driver string, f TLSConfigFunc) {
+	if f == nil {
+		panic("must specify a non-nil TLSConfigFunc")
+	}
+	tlsConfigFuncs.Lock()
+	defer tlsConfigFuncs.Unlock()
+	if tlsConfigFuncs.m == nil {
+		tlsConfigFuncs.m = make(map[string]TLSConfigFunc)
+	}
+	tlsConfigFuncs.m[driver] = f
+}
+
+// TLSConfig builds a TLS config for the URL from its portable TLS query
+// parameters:
+//
+//	tls_ca                    CA certificates (PEM) file used to verify the server
+//	tls_cert, tls_key         client certificate and key (PEM) files
+//	tls_insecure_skip_verify  skip verifying the server's certificate (bool)
+//	tls_server_name           server name used to verify the server's certificate
+//	tls_min_version           minimum TLS version ("1.0", "1.1", "1.2", or "1.3")
+//	tls_cipher_suites         comma separated TLS 1.0-1.2 cipher suite names (ie,
+//	                          "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"), see [tls.CipherSuites]
+//
+// The "sslrootcert", "sslcert" and "sslkey", and "clientcert" and
+// "clientkey" parameters are used when the equivalent portable parameters are
+// not specified. The server name defaults to the URL's host. Files are read
+// using [OpenFile], and a [CertError] is returned when a client certificate
+// or key file is missing or invalid.
+//
+// Used by [Open] for drivers accepting registered TLS configs (see
+// [RegisterTLSConfigFunc]), and for drivers that accept a TLS config
+// programmatically.
+func TLSConfig(u *URL) (*tls.Config, error) {
+	host := u.Hostname()
+	if u.hostPortDB != nil {
+		host = u.hostPortDB[0]
+	}
+	return buildTLSConfig(u.Query(), host)
+}
+
+// buildTLSConfig builds a TLS config from the query parameters.
+func buildTLSConfig(q url.Values, host string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: defaultString(q.Get("tls_server_name"), host),
+	}
+	// root certificates
+	if name := tlsParam(q, "tls_ca"); name != "" && name != "system" {
+		buf, err := readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(buf) {
+			return nil, fmt.Errorf("%s: no certificates found", name)
+		}
+	}
+	// client certificate
+	if certFile, keyFile := tlsParam(q, "tls_cert"), tlsParam(q, "tls_key"); certFile != "" || keyFile != "" {
+		cert, err := loadClientCert(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	// verification
+	skip, err := tlsSkipVerify(q)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.InsecureSkipVerify = skip
+	// policy
+	if tlsConfig.MinVersion, err = tlsMinVersion(q); err != nil {
+		return nil, err
+	}
+	if tlsConfig.CipherSuites, err = tlsCipherSuites(q); err != nil {
+		return nil, err
+	}
+	return tlsConfig, nil
+}
+
+// tlsMinVersion returns the value of the portable "tls_min_version" query
+// parameter.
+func tlsMinVersion(q url.Values) (uint16, error) {
+	s := q.Get("tls_min_version")
+	if s == "" {
+		return 0, nil
+	}
+	v, ok := tlsVersions[s]
+	if !ok {
+		return 0, &ParamError{Param: "tls_min_version", Value: s, Err: ErrInvalidParameter}
+	}
+	return v, nil
+}
+
+// tlsCipherSuites returns the value of the portable "tls_cipher_suites" query
+// parameter. Only the secure cipher suites (see [tls.CipherSuites]) are
+// accepted.
+func tlsCipherSuites(q url.Values) ([]uint16, error) {
+	s := q.Get("tls_cipher_suites")
+	if s == "" {
+		return nil, nil
+	}
+	var ids []uint16
+	for _, name := range strings.Split(s, ",") {
+		i := slices.IndexFunc(tls.CipherSuites(), func(c *tls.CipherSuite) bool {
+			return c.Name == strings.TrimSpace(name)
+		})
+		if i == -1 {
+			return nil, &ParamError{Param: "tls_cipher_suites", Value: name, Err: ErrInvalidParameter}
+		}
+		ids = append(ids, tls.CipherSuites()[i].ID)
+	}
+	return ids, nil
+}
+
+// tlsPolicy validates the portable TLS policy query parameters.
+func tlsPolicy(q url.Values) error {
+	if _, err := tlsMinVersion(q); err != nil {
+		return err
+	}
+	_, err := tlsCipherSuites(q)
+	return err
+}
+
+// tlsConfigName returns the name used to register the TLS config for the
+// portable TLS query parameters.
+func tlsConfigName(q url.Values) string {
+	h := fnv.New64a()
+	for _, k := range tlsKeys {
+		_, _ = io.WriteString(h, k+"="+tlsParam(q, k)+"\x00")
+	}
+	return "dburl_" + strconv.FormatUint(h.Sum64(), 16)
+}
+
+// registerTLSConfig registers the URL's TLS config with the driver, when the
+// driver only accepts TLS configs registered by name.
+func registerTLSConfig(u *URL) error {
+	q := u.Query()
+	scheme, ok := lookupScheme(u.UnaliasedDriver)
+	if !ok || !contains(tlsConfigFamilies, wireFamily(scheme)) || !hasAnyKey(q, tlsKeys) && !hasCertAlias(q) {
+		return nil
+	}
+	tlsConfigFuncs.RLock()
+	f := tlsConfigFuncs.m[u.Driver]
+	tlsConfigFuncs.RUnlock()
+	if f == nil {
+		return &ParamError{Driver: u.Driver, Param: "tls", Value: tlsConfigName(q), Err: ErrMissingTLSConfigFunc}
+	}
+	tlsConfig, err := TLSConfig(u)
+	if err != nil {
+		return err
+	}
+	return f(tlsConfigName(q), tlsConfig)
+}
+
+// tlsParam returns the value of the portable TLS query parameter, or the
+// value of its first specified alias.
+func tlsParam(q url.Values, key string) string {
+	if q.Has(key) {
+		return q.Get(key)
+	}
+	for _, k := range tlsAliases[key] {
+		if q.Has(k) {
+			return q.Get(k)
+		}
+	}
+	return ""
+}
+
+// hasCertAlias returns true when a client certificate alias query parameter
+// is specified.
+func hasCertAlias(q url.Values) bool {
+	return hasAnyKey(q, tlsAliases["tls_cert"]) || hasAnyKey(q, tlsAliases["tls_key"])
+}
+
+// normalizeCertParams renames the client certificate alias query parameters
+// to the portable "tls_cert" and "tls_key" parameters, for the database
+// families in certFamilies.
+func normalizeCertParams(q url.Values, scheme *Scheme) (bool, error) {
+	if !contains(certFamilies, wireFamily(scheme)) {
+		return false, nil
+	}
+	var changed bool
+	for _, key := range []string{"tls_cert", "tls_key"} {
+		for _, k := range tlsAliases[key] {
+			if !q.Has(k) {
+				continue
+			}
+			if v := q.Get(k); q.Has(key) && q.Get(key) != v {
+				return false, &ParamError{Driver: scheme.Driver, Param: k, Value: v, Err: ErrInvalidParameter}
+			}
+			q.Set(key, q.Get(k))
+			q.Del(k)
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// ValidateClientCert loads and validates the client certificate and key files
+// specified by the URL's "tls_cert" and "tls_key" query parameters (or their
+// "sslcert" and "sslkey", and "clientcert" and "clientkey" aliases), if any,
+// returning a [CertError] when a file is missing or the certificate and key
+// are invalid. Files are read using [OpenFile].
+//
+// Called by [OpenURL] when the URL was parsed using
+// [WithClientCertValidation].
+func ValidateClientCert(u *URL) error {
+	q := u.Query()
+	certFile, keyFile := tlsParam(q, "tls_cert"), tlsParam(q, "tls_key")
+	if certFile == "" && keyFile == "" {
+		return nil
+	}
+	_, err := loadClientCert(certFile, keyFile)
+	return err
+}
+
+// loadClientCert loads the client certificate and key (PEM) files. When only
+// one file is specified, it must contain both the certificate and key.
+func loadClientCert(certFile, keyFile string) (tls.Certificate, error) {
+	certFile, keyFile = defaultString(certFile, keyFile), defaultString(keyFile, certFile)
+	certPEM, err := readFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, certFileError("tls_cert", certFile, err)
+	}
+	keyPEM, err := readFile(keyFile)
+	if err != nil {
+		return tls.Certificate{}, certFileError("tls_key", keyFile, err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, &CertError{Param: "tls_key", File: keyFile, Err: ErrInvalidKeyPair}
+	}
+	return cert, nil
+}
+
+// certFileError returns a [CertError] for a client certificate or key file
+// read error.
+func certFileError(param, file string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return &CertError{Param: param, File: file, Err: ErrMissingCertFile}
+	}
+	return &CertError{Param: param, File: file, Err: err}
+}
+
+// readFile reads the named file using [OpenFile].
+func readFile(name string) ([]byte, error) {
+	f, err := OpenFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,204 @@
+package dburl
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// paramRename renames a native query parameter's key and value for another
+// driver, returning false when the value cannot be translated.
+type paramRename func(v string) (string, string, bool)
+
+// nativeParams are the native query parameters only understood by a Go
+// driver, by driver name.
+var nativeParams = map[string][]string{
+	"mysql": {
+		"allowCleartextPasswords", "allowNativePasswords", "checkConnLiveness",
+		"clientFoundRows", "columnsWithAlias", "interpolateParams", "loc",
+		"maxAllowedPacket", "multiStatements", "parseTime", "rejectReadOnly",
+	},
+	"pgx": {
+		"default_query_exec_mode", "description_cache_capacity",
+		"pool_health_check_period", "pool_max_conn_idle_time",
+		"pool_max_conn_lifetime", "pool_max_conns", "pool_min_conns",
+		"statement_cache_capacity",
+	},
+	"postgres": {
+		"binary_parameters", "disable_prepared_binary_result",
+	},
+	"sqlite3": {
+		"_busy_timeout", "_cache_size", "_fk", "_foreign_keys", "_journal",
+		"_journal_mode", "_loc", "_locking_mode", "_mutex", "_sync",
+		"_synchronous", "_timeout",
+	},
+	"moderncsqlite": {
+		"_pragma", "_time_format",
+	},
+}
+
+// paramRenames are the native query parameter renames between Go drivers, by
+// source and target driver name.
+var paramRenames = map[[2]string]map[string]paramRename{
+	{"sqlite3", "moderncsqlite"}: {
+		"_busy_timeout": toPragma("busy_timeout"),
+		"_fk":           toPragma("foreign_keys"),
+		"_foreign_keys": toPragma("foreign_keys"),
+		"_journal":      toPragma("journal_mode"),
+		"_journal_mode": toPragma("journal_mode"),
+		"_locking_mode": toPragma("locking_mode"),
+		"_sync":         toPragma("synchronous"),
+		"_synchronous":  toPragma("synchronous"),
+		"_timeout":      toPragma("busy_timeout"),
+		"_cache_size":   toPragma("cache_size"),
+	},
+	{"moderncsqlite", "sqlite3"}: {
+		"_pragma": fromPragma,
+	},
+}
+
+// toPragma returns a rename to a moderncsqlite "_pragma" parameter.
+func toPragma(name string) paramRename {
+	return func(v string) (string, string, bool) {
+		return "_pragma", name + "(" + v + ")", true
+	}
+}
+
+// fromPragma renames a moderncsqlite "_pragma" parameter (ie,
+// "foreign_keys(1)") to the sqlite3 parameter (ie, "_foreign_keys=1").
+func fromPragma(v string) (string, string, bool) {
+	name, value, ok := strings.Cut(v, "(")
+	if !ok || !strings.HasSuffix(value, ")") {
+		return "", "", false
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "busy_timeout", "cache_size", "foreign_keys", "journal_mode", "locking_mode", "synchronous":
+		return "_" + name, strings.TrimSuffix(value, ")"), true
+	}
+	return "", "", false
+}
+
+// Translate re-targets the URL to another wire compatible scheme (ie,
+// postgres to pgx, mysql to mymysql, postgres to cockroachdb), returning the
+// parsed URL for the target scheme.
+//
+// Portable query parameters (ie, "charset", "timezone") are translated by
+// the target scheme when parsed, and native query parameters known to a
+// driver are renamed for the target driver (ie, sqlite3's "_fk=1" to
+// moderncsqlite's "_pragma=foreign_keys(1)"). Parameters that cannot be
+// translated are removed, and the translated URL is returned along with a
+// [TranslateError] listing the removed parameters.
+//
+// Returns [ErrIncompatibleScheme] when the target scheme is not wire
+// compatible with the URL's scheme.
+func Translate(u *URL, targetScheme string) (*URL, error) {
+	src, ok := lookupScheme(u.UnaliasedDriver)
+	if !ok {
+		return nil, ErrUnknownDatabaseScheme
+	}
+	dst, ok := lookupScheme(targetScheme)
+	if !ok {
+		return nil, ErrUnknownDatabaseScheme
+	}
+	if wireFamily(src) != wireFamily(dst) {
+		return nil, &TranslateError{From: src.Driver, To: dst.Driver, Err: ErrIncompatibleScheme}
+	}
+	q, dropped := translateQuery(u.Query(), src, dst)
+	v := u.URL
+	v.Scheme = targetScheme
+	if _, transport, ok := strings.Cut(u.OriginalScheme, "+"); ok {
+		v.Scheme += "+" + transport
+	}
+	if len(dropped) != 0 || goDriver(src) != goDriver(dst) {
+		v.RawQuery = q.Encode()
+	}
+	o := u.opts
+	if o == nil {
+		o = newOptions(context.Background())
+	}
+	z, err := parse(v.String(), o)
+	switch {
+	case err != nil:
+		return nil, err
+	case len(dropped) != 0:
+		return z, &TranslateError{From: src.Driver, To: dst.Driver, Params: dropped, Err: ErrUntranslatableParameter}
+	}
+	return z, nil
+}
+
+// translateQuery translates the query parameters from the source to the
+// target scheme, returning the translated parameters and the sorted keys of
+// the parameters that could not be translated.
+func translateQuery(q url.Values, src, dst *Scheme) (url.Values, []string) {
+	from, to := goDriver(src), goDriver(dst)
+	if from == to {
+		return q, nil
+	}
+	out, dropped := make(url.Values), make(map[string]bool)
+	renames := paramRenames[[2]string{from, to}]
+	names := make([]string, 0, len(q))
+	for k := range q {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		values := q[k]
+		f, rename := renames[k]
+		switch {
+		case rename:
+			for _, s := range values {
+				key, value, ok := f(s)
+				if !ok {
+					dropped[k] = true
+					continue
+				}
+				out.Add(key, value)
+			}
+		case contains(nativeParams[from], k) && !contains(nativeParams[to], k),
+			!portableSupported(k, dst):
+			dropped[k] = true
+		default:
+			out[k] = append(out[k], values...)
+		}
+	}
+	keys := make([]string, 0, len(dropped))
+	for k := range dropped {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return out, keys
+}
+
+// portableSupported returns false when the query parameter is a portable
+// query parameter not supported by the scheme.
+func portableSupported(key string, scheme *Scheme) bool {
+	for _, p := range portableParams {
+		if !contains(p.keys, key) {
+			continue
+		}
+		f, ok := p.drivers[scheme.Driver]
+		if !ok {
+			f = p.drivers[family(scheme)]
+		}
+		return f != nil
+	}
+	return true
+}
+
+// wireFamily returns the wire protocol family for the scheme.
+func wireFamily(scheme *Scheme) string {
+	if f, ok := envFamilies[scheme.Driver]; ok {
+		return f
+	}
+	return family(scheme)
+}
+
+// goDriver returns the name of the Go driver used by the scheme.
+func goDriver(scheme *Scheme) string {
+	if scheme.Override != "" {
+		return scheme.Override
+	}
+	return scheme.Driver
+}
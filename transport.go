@@ -0,0 +1,110 @@
+package dburl
+
+import (
+	"strings"
+)
+
+// TransportParam is a declarative mapping of a [URL]'s transport to the value
+// of a DSN parameter, for drivers where the transport is expressed as part of
+// the DSN (ie, the "tcp" or "unix" network of a mysql DSN, or the "grpcs"
+// scheme of a ydb DSN).
+type TransportParam struct {
+	// Param is the name of the DSN parameter (ie, "net", "scheme").
+	Param string `json:"param"`
+	// Values maps a transport (ie, "tcp", "https") to the parameter's value.
+	// A scheme alias (ie, "ydbs") may also be used as a key, and takes
+	// precedence over the transport.
+	Values map[string]string `json:"values"`
+	// Query toggles adding the parameter to the URL's query prior to
+	// generating the DSN, allowing schemes using a generator such as
+	// [GenFromURL] to pass the transport to the driver without a custom
+	// generator.
+	Query bool `json:"query,omitempty"`
+}
+
+// value returns the parameter value for the URL.
+func (p *TransportParam) value(u *URL) (string, bool) {
+	name := strings.ToLower(u.OriginalScheme)
+	if i := strings.IndexRune(name, '+'); i != -1 {
+		name = name[:i]
+	}
+	if v, ok := p.Values[name]; ok {
+		return v, true
+	}
+	v, ok := p.Values[strings.ToLower(u.Transport)]
+	return v, ok
+}
+
+// copy returns a copy of the transport param.
+func (p *TransportParam) copy() TransportParam {
+	z := TransportParam{
+		Param:  p.Param,
+		Values: make(map[string]string, len(p.Values)),
+		Query:  p.Query,
+	}
+	for k, v := range p.Values {
+		z.Values[k] = v
+	}
+	return z
+}
+
+// EffectiveTransportParam returns the DSN parameter and value for the URL's
+// transport, as declared by the scheme's TransportParam.
+func (u *URL) EffectiveTransportParam() (string, string, bool) {
+	scheme, ok := lookupScheme(u.UnaliasedDriver)
+	if !ok || scheme.TransportParam == nil {
+		return "", "", false
+	}
+	v, ok := scheme.TransportParam.value(u)
+	if !ok {
+		return "", "", false
+	}
+	return scheme.TransportParam.Param, v, true
+}
+
+// TransportParams returns the transport parameter mappings of the registered
+// schemes, keyed by driver.
+func TransportParams() map[string]TransportParam {
+	m := make(map[string]TransportParam)
+	for _, scheme := range registered() {
+		if scheme.TransportParam != nil {
+			m[scheme.Driver] = scheme.TransportParam.copy()
+		}
+	}
+	return m
+}
+
+// applyTransportParam adds the scheme's transport parameter to the URL's
+// query, when the scheme's TransportParam has Query set.
+func applyTransportParam(u *URL, scheme *Scheme) {
+	p := scheme.TransportParam
+	if p == nil || !p.Query {
+		return
+	}
+	if v, ok := p.value(u); ok {
+		q := u.Query()
+		q.Set(p.Param, v)
+		u.RawQuery = q.Encode()
+	}
+}
+
+// querySocket returns the unix socket specified via the URL's query, when the
+// URL has no host (ie, "my://user:pass@/dbname?socket=/var/run/mysqld/mysqld.sock",
+// or the socket directory of "pg:///dbname?host=/var/run/postgresql"), for
+// schemes allowing the unix transport.
+func querySocket(u *URL, scheme *Scheme) (string, bool) {
+	if u.Host != "" || !scheme.Transport.Allows("unix") {
+		return "", false
+	}
+	switch family(scheme) {
+	case "mysql":
+		if v := u.Query().Get("socket"); v != "" {
+			return v, true
+		}
+	case "postgres", "nzgo":
+		if v := u.Query().Get("host"); strings.HasPrefix(v, "/") || strings.HasPrefix(v, "@") {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,124 @@
+// Package tunnel provides an opt-in open hook for connecting to databases
+// through a tunnel (ie, a SSH bastion host), specified with the "tunnel" query
+// parameter of a database URL:
+//
+//	pg://user:pass@db.internal/mydb?tunnel=ssh://bastion@bastion.example.com
+//
+// As establishing a tunnel requires packages outside of Go's standard library
+// (ie, golang.org/x/crypto/ssh), tunnels are established by a [Forwarder]
+// registered for the tunnel URL's scheme:
+//
+//	import _ "github.com/xo/dburl/tunnel"
+//
+//	tunnel.Register("ssh", func(ctx context.Context, tunnelURL *url.URL, network, addr string) (string, error) {
+//		// listen on a local address, forwarding connections to addr via tunnelURL
+//	})
+//
+// When opened with [dburl.Open], the database's address is forwarded through
+// the tunnel, and the URL is opened with its host replaced by the forwarder's
+// local address.
+package tunnel
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/xo/dburl"
+)
+
+func init() {
+	dburl.RegisterOpenHook(Hook)
+}
+
+// Forwarder is a func that forwards the database's network address through
+// the tunnel, returning the local address (ie, "127.0.0.1:15432") accepting
+// forwarded connections. The forwarder is responsible for the lifetime of the
+// tunnel.
+type Forwarder func(ctx context.Context, tunnelURL *url.URL, network, addr string) (string, error)
+
+// forwarders are the registered forwarders.
+var forwarders struct {
+	sync.RWMutex
+	m map[string]Forwarder
+}
+
+// Register registers the forwarder for the tunnel URL scheme (ie, "ssh").
+func Register(scheme string, f Forwarder) {
+	if f == nil {
+		panic("must specify a non-nil Forwarder")
+	}
+	forwarders.Lock()
+	defer forwarders.Unlock()
+	if forwarders.m == nil {
+		forwarders.m = make(map[string]Forwarder)
+	}
+	forwarders.m[strings.ToLower(scheme)] = f
+}
+
+// Hook is the [dburl.OpenHook] registered by the package, forwarding the
+// address of URLs having a "tunnel" query parameter through the tunnel prior
+// to opening.
+func Hook(next dburl.OpenFunc) dburl.OpenFunc {
+	return func(u *dburl.URL) (*sql.DB, error) {
+		z, err := Forward(context.Background(), u)
+		if err != nil {
+			return nil, err
+		}
+		return next(z)
+	}
+}
+
+// Forward forwards the database address of the URL through the tunnel of its
+// "tunnel" query parameter, returning a URL with its host replaced by the
+// forwarder's local address, and the "tunnel" query parameter removed.
+// Returns the URL unchanged when it has no "tunnel" query parameter.
+func Forward(ctx context.Context, u *dburl.URL) (*dburl.URL, error) {
+	q := u.Query()
+	if !q.Has("tunnel") {
+		return u, nil
+	}
+	tunnelURL, err := url.Parse(q.Get("tunnel"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tunnel: %w", err)
+	}
+	forwarders.RLock()
+	f, ok := forwarders.m[strings.ToLower(tunnelURL.Scheme)]
+	forwarders.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("tunnel scheme %q: %w", tunnelURL.Scheme, ErrUnknownTunnel)
+	}
+	network, addr, _, err := dburl.DialAddr(u)
+	switch {
+	case err != nil:
+		return nil, err
+	case !strings.HasPrefix(network, "tcp"):
+		return nil, fmt.Errorf("network %q: %w", network, ErrUnsupportedNetwork)
+	}
+	local, err := f(ctx, tunnelURL, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	q.Del("tunnel")
+	v := u.URL
+	v.Host, v.RawQuery = local, q.Encode()
+	return dburl.Parse((&dburl.URL{URL: v, OriginalScheme: u.OriginalScheme}).String())
+}
+
+// Error is a error.
+type Error string
+
+// Error satisfies the error interface.
+func (err Error) Error() string {
+	return string(err)
+}
+
+const (
+	// ErrUnknownTunnel is the unknown tunnel error.
+	ErrUnknownTunnel Error = "unknown tunnel"
+	// ErrUnsupportedNetwork is the unsupported network error.
+	ErrUnsupportedNetwork Error = "unsupported network"
+)
@@ -0,0 +1,272 @@
+// Package urllist provides a mechanism for reading named database URL lists,
+// the non-secret half of connection configuration complementing passfiles.
+//
+// A URL list is either a text file (ie, urls.txt) of "name = url" lines:
+//
+//	# urls.txt
+//	orders = pg://orders.db.example.com/orders?sslmode=require
+//	cache  = my://${CACHE_HOST}/cache
+//
+// or a YAML file (ie, urls.yaml) containing a mapping of names to URLs:
+//
+//	# urls.yaml
+//	orders: pg://orders.db.example.com/orders?sslmode=require
+//	cache: "my://${CACHE_HOST}/cache"
+//
+// Blank lines and comments are skipped, and environment variables ($VAR or
+// ${VAR}) in URLs are expanded when loaded. Only the subset of YAML
+// consisting of a single mapping of string values is supported.
+package urllist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/dburl/passfile"
+)
+
+// Entry is a named URL list entry.
+type Entry struct {
+	// Name is the entry name.
+	Name string
+	// URL is the unexpanded URL string.
+	URL string
+}
+
+// Parse parses text URL list entries ("name = url") from the reader.
+func Parse(r io.Reader) ([]Entry, error) {
+	return parse(r, "=")
+}
+
+// ParseYAML parses YAML URL list entries ("name: url") from the reader.
+func ParseYAML(r io.Reader) ([]Entry, error) {
+	return parse(r, ":")
+}
+
+// parse parses URL list entries from the reader, where each entry's name and
+// URL are separated by sep.
+func parse(r io.Reader, sep string) ([]Entry, error) {
+	var entries []Entry
+	i, s := 0, bufio.NewScanner(r)
+	for s.Scan() {
+		i++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || sep == ":" && line == "---" {
+			continue
+		}
+		k, v, ok := strings.Cut(line, sep)
+		if !ok {
+			return nil, &LineError{i, ErrInvalidValue}
+		}
+		name, err := parseName(strings.TrimSpace(k))
+		if err != nil {
+			return nil, &LineError{i, err}
+		}
+		for _, entry := range entries {
+			if entry.Name == name {
+				return nil, &LineError{i, ErrDuplicateName}
+			}
+		}
+		urlstr, err := parseValue(strings.TrimSpace(v))
+		if err != nil {
+			return nil, &LineError{i, err}
+		}
+		entries = append(entries, Entry{Name: name, URL: urlstr})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseName parses a bare or quoted name.
+func parseName(s string) (string, error) {
+	switch {
+	case s == "":
+		return "", ErrInvalidName
+	case s[0] == '"' || s[0] == '\'':
+		return parseValue(s)
+	}
+	for _, c := range s {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return "", ErrInvalidName
+		}
+	}
+	return s, nil
+}
+
+// parseValue parses a bare, double quoted ("..."), or single quoted ('...')
+// value, followed by an optional comment. Comments following bare values
+// must be preceded by whitespace, as URLs may contain '#'.
+func parseValue(s string) (string, error) {
+	if s == "" {
+		return "", ErrInvalidValue
+	}
+	switch s[0] {
+	case '"':
+		// find closing quote, skipping escapes
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				if !isComment(s[i+1:]) {
+					return "", ErrInvalidValue
+				}
+				v, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", ErrInvalidValue
+				}
+				return v, nil
+			}
+		}
+		return "", ErrInvalidValue
+	case '\'':
+		if i := strings.IndexByte(s[1:], '\''); i != -1 && isComment(s[i+2:]) {
+			return s[1 : i+1], nil
+		}
+		return "", ErrInvalidValue
+	}
+	if i := strings.Index(s, " #"); i != -1 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if i := strings.Index(s, "\t#"); i != -1 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// isComment returns true when s is empty or a comment.
+func isComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#")
+}
+
+// ParseFile parses URL list entries contained in file, parsing files with a
+// .yaml or .yml extension as YAML, and otherwise as text.
+func ParseFile(file string) ([]Entry, error) {
+	fi, err := os.Stat(file)
+	switch {
+	case err != nil:
+		return nil, &FileError{file, err}
+	case fi.IsDir():
+		return nil, &FileError{file, passfile.ErrMustNotBeDirectory}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, &FileError{file, err}
+	}
+	defer f.Close()
+	var entries []Entry
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		entries, err = ParseYAML(f)
+	default:
+		entries, err = Parse(f)
+	}
+	if err != nil {
+		return nil, &FileError{file, err}
+	}
+	return entries, nil
+}
+
+// URLs expands environment variables in the entries' URL strings (see
+// [os.ExpandEnv]), returning the parsed URLs keyed by name.
+func URLs(entries []Entry) (map[string]*dburl.URL, error) {
+	m := make(map[string]*dburl.URL, len(entries))
+	for _, entry := range entries {
+		u, err := dburl.Parse(os.ExpandEnv(entry.URL))
+		if err != nil {
+			return nil, &EntryError{entry.Name, err}
+		}
+		m[entry.Name] = u
+	}
+	return m, nil
+}
+
+// Load loads the URL list contained in file, returning the parsed URLs keyed
+// by name.
+//
+// Equivalent to ParseFile(file) followed by [URLs].
+func Load(file string) (map[string]*dburl.URL, error) {
+	entries, err := ParseFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m, err := URLs(entries)
+	if err != nil {
+		return nil, &FileError{file, err}
+	}
+	return m, nil
+}
+
+// Error is a error.
+type Error string
+
+// Error satisfies the error interface.
+func (err Error) Error() string {
+	return string(err)
+}
+
+const (
+	// ErrInvalidName is the invalid name error.
+	ErrInvalidName Error = "invalid name"
+	// ErrInvalidValue is the invalid value error.
+	ErrInvalidValue Error = "invalid value"
+	// ErrDuplicateName is the duplicate name error.
+	ErrDuplicateName Error = "duplicate name"
+)
+
+// FileError is a file error.
+type FileError struct {
+	File string
+	Err  error
+}
+
+// Error satisfies the error interface.
+func (err *FileError) Error() string {
+	return fmt.Sprintf("urllist %q: %v", err.File, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *FileError) Unwrap() error {
+	return err.Err
+}
+
+// LineError is a line error.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+// Error satisfies the error interface.
+func (err *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *LineError) Unwrap() error {
+	return err.Err
+}
+
+// EntryError is an entry error.
+type EntryError struct {
+	Name string
+	Err  error
+}
+
+// Error satisfies the error interface.
+func (err *EntryError) Error() string {
+	return fmt.Sprintf("entry %q: %v", err.Name, err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *EntryError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,133 @@
+package urllist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xo/dburl"
+)
+
+func TestParse(t *testing.T) {
+	entries, err := Parse(strings.NewReader(textFile))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(entries, expEntries) {
+		t.Errorf("entries does not equal expected:\nexp:%#v\n---\ngot:%#v", expEntries, entries)
+	}
+	entries, err = ParseYAML(strings.NewReader(yamlFile))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(entries, expEntries) {
+		t.Errorf("entries does not equal expected:\nexp:%#v\n---\ngot:%#v", expEntries, entries)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		s    string
+		yaml bool
+		line int
+		exp  error
+	}{
+		{"a = pg://\na = pg://", false, 2, ErrDuplicateName},
+		{"a b = pg://", false, 1, ErrInvalidName},
+		{"a", false, 1, ErrInvalidValue},
+		{"a =", false, 1, ErrInvalidValue},
+		{"# urls\na = \"pg://\" extra", false, 2, ErrInvalidValue},
+		{"a: 'pg://", true, 1, ErrInvalidValue},
+		{"a = pg://", true, 1, ErrInvalidName},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			f := Parse
+			if test.yaml {
+				f = ParseYAML
+			}
+			_, err := f(strings.NewReader(test.s))
+			var lerr *LineError
+			switch {
+			case !errors.As(err, &lerr):
+				t.Fatalf("expected line error, got: %v", err)
+			case lerr.Line != test.line:
+				t.Errorf("expected line %d, got: %d", test.line, lerr.Line)
+			case !errors.Is(err, test.exp):
+				t.Errorf("expected %v, got: %v", test.exp, err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("CACHE_HOST", "cache.example.com")
+	dir := t.TempDir()
+	for name, s := range map[string]string{"urls.txt": textFile, "urls.yaml": yamlFile} {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, name)
+			if err := os.WriteFile(file, []byte(s), 0o644); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			m, err := Load(file)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			exp := map[string]string{
+				"orders":      "dbname=orders host=orders.db.example.com sslmode=require",
+				"cache":       "tcp(cache.example.com:3306)/cache",
+				"dev.reports": "/var/lib/reports.db",
+			}
+			if len(m) != len(exp) {
+				t.Fatalf("expected %d urls, got: %d", len(exp), len(m))
+			}
+			for k, dsn := range exp {
+				if u, ok := m[k]; !ok || u.DSN != dsn {
+					t.Errorf("expected %s dsn %q, got: %v", k, dsn, u)
+				}
+			}
+		})
+	}
+	file := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(file, []byte("bad = unknown://localhost\n"), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	_, err := Load(file)
+	var eerr *EntryError
+	switch {
+	case !errors.As(err, &eerr):
+		t.Errorf("expected entry error, got: %v", err)
+	case eerr.Name != "bad":
+		t.Errorf("expected entry %q, got: %q", "bad", eerr.Name)
+	case !errors.Is(err, dburl.ErrUnknownDatabaseScheme):
+		t.Errorf("expected %v, got: %v", dburl.ErrUnknownDatabaseScheme, err)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected %v, got: %v", os.ErrNotExist, err)
+	}
+}
+
+var expEntries = []Entry{
+	{"orders", "pg://orders.db.example.com/orders?sslmode=require"},
+	{"cache", "my://${CACHE_HOST}/cache"},
+	{"dev.reports", "sq:/var/lib/reports.db"},
+}
+
+const textFile = `# urls.txt
+orders = pg://orders.db.example.com/orders?sslmode=require # primary
+
+cache       = "my://${CACHE_HOST}/cache"
+dev.reports = 'sq:/var/lib/reports.db'
+`
+
+const yamlFile = `# urls.yaml
+---
+orders: pg://orders.db.example.com/orders?sslmode=require # primary
+
+cache: "my://${CACHE_HOST}/cache"
+"dev.reports": 'sq:/var/lib/reports.db'
+`
@@ -0,0 +1,145 @@
+// Code generated by gen.go. DO NOT EDIT.
+
+package dburl
+
+// builtinIndex returns the index in [BaseSchemes] of the built-in scheme with
+// the name or alias, or -1 when not a built-in scheme name or alias.
+func builtinIndex(name string) int {
+	switch name {
+	case "file", "fi":
+		return 0
+	case "memory", "mem", "mm":
+		return 1
+	case "tmpfile", "tmp", "tf":
+		return 2
+	case "mysql", "mariadb", "maria", "percona", "aurora", "my":
+		return 3
+	case "oracle", "ora", "oci", "oci8", "odpi", "odpi-c", "or":
+		return 4
+	case "postgres", "pg", "postgresql", "pgsql":
+		return 5
+	case "sqlite3", "sqlite", "sq":
+		return 6
+	case "sqlserver", "ms", "mssql", "azuresql":
+		return 7
+	case "cockroachdb", "cr", "cockroach", "crdb", "cdb":
+		return 8
+	case "greenplum", "gp":
+		return 9
+	case "materialize", "mz":
+		return 10
+	case "memsql", "me":
+		return 11
+	case "redshift", "rs":
+		return 12
+	case "risingwave", "rw":
+		return 13
+	case "timescale", "ts", "tsdb", "timescaledb":
+		return 14
+	case "sparksql", "ss", "kyuubi", "thrift", "spark":
+		return 15
+	case "sqld", "lq", "libsql", "turso":
+		return 16
+	case "tidb", "ti":
+		return 17
+	case "vitess", "vt":
+		return 18
+	case "doris", "dr":
+		return 19
+	case "starrocks", "sr":
+		return 20
+	case "godror", "gr":
+		return 21
+	case "moderncsqlite", "mq", "modernsqlite":
+		return 22
+	case "mymysql", "zm", "mymy":
+		return 23
+	case "pgx", "px":
+		return 24
+	case "adodb", "ado", "ad":
+		return 25
+	case "awsathena", "s3", "aws", "athena":
+		return 26
+	case "avatica", "phoenix", "av":
+		return 27
+	case "bigquery", "bq":
+		return 28
+	case "clickhouse", "ch":
+		return 29
+	case "cosmos", "cm":
+		return 30
+	case "cql", "ca", "cassandra", "datastax", "scy", "scylla":
+		return 31
+	case "csvq", "csv", "tsv", "json", "cs":
+		return 32
+	case "d1", "cfd1":
+		return 33
+	case "databend", "dd", "bend":
+		return 34
+	case "databricks", "br", "brick", "bricks", "databrick":
+		return 35
+	case "dolt", "do", "doltdb":
+		return 36
+	case "duckdb", "dk", "ddb", "duck":
+		return 37
+	case "godynamo", "dy", "dyn", "dynamo", "dynamodb":
+		return 38
+	case "exasol", "ex", "exa":
+		return 39
+	case "firebirdsql", "fb", "firebird":
+		return 40
+	case "flightsql", "fl", "flight":
+		return 41
+	case "chai", "ci", "chaisql", "genji":
+		return 42
+	case "h2":
+		return 43
+	case "hdb", "sa", "saphana", "sap", "hana":
+		return 44
+	case "heavydb", "omnisci", "mapd", "he":
+		return 45
+	case "hive", "hive2", "hi":
+		return 46
+	case "ignite", "ig", "gridgain":
+		return 47
+	case "interbase", "ib":
+		return 48
+	case "impala", "im":
+		return 49
+	case "kinetica", "ki":
+		return 50
+	case "maxcompute", "mc", "odps":
+		return 51
+	case "n1ql", "couchbase", "n1":
+		return 52
+	case "nzgo", "nz", "netezza":
+		return 53
+	case "odbc", "od":
+		return 54
+	case "oleodbc", "oo", "ole":
+		return 55
+	case "ots", "tablestore", "ot":
+		return 56
+	case "presto", "prestodb", "prestos", "prs", "prestodbs", "pr":
+		return 57
+	case "ql", "cznic", "cznicql":
+		return 58
+	case "ramsql", "rm", "ram":
+		return 59
+	case "snowflake", "sf":
+		return 60
+	case "spanner", "sp":
+		return 61
+	case "tds", "ax", "ase", "sapase":
+		return 62
+	case "trino", "trinos", "trs", "tr":
+		return 63
+	case "vertica", "ve":
+		return 64
+	case "voltdb", "volt", "vdb", "vo":
+		return 65
+	case "ydb", "yd", "yds", "ydbs":
+		return 66
+	}
+	return -1
+}